@@ -0,0 +1,264 @@
+package admin
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/anycable/anycable-go/hub"
+	"github.com/anycable/anycable-go/node"
+	"github.com/anycable/anycable-go/server"
+	"github.com/apex/log"
+)
+
+const (
+	defaultPath = "/_admin"
+
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+// Config contains admin API configuration
+type Config struct {
+	// Port to listen on (the main server port is used by default)
+	Port int
+	// Path prefix for admin API endpoints
+	Path string
+	// Secret token to authorize requests (API is disabled if empty)
+	Secret string
+}
+
+// NewConfig builds a new config for admin API
+func NewConfig() Config {
+	return Config{
+		Path: defaultPath,
+	}
+}
+
+// Enabled returns true if the secret is provided
+func (c *Config) Enabled() bool {
+	return c.Secret != ""
+}
+
+// Inspector provides access to the node state
+type Inspector interface {
+	Streams() []*hub.StreamInfo
+	SessionIDs() []string
+	SessionInfo(sid string) *node.SessionInfo
+	SessionsInfoByIdentifiers(identifiers string) []*node.SessionInfo
+}
+
+// Page contains a part of a collection
+type Page struct {
+	Total  int         `json:"total"`
+	Offset int         `json:"offset"`
+	Limit  int         `json:"limit"`
+	Items  interface{} `json:"items"`
+}
+
+// Server serves admin API requests
+type Server struct {
+	port       int
+	path       string
+	authHeader string
+	server     *server.HTTPServer
+	inspector  Inspector
+	log        *log.Entry
+}
+
+// NewServer builds a new admin API server
+func NewServer(inspector Inspector, config *Config) *Server {
+	return &Server{
+		inspector:  inspector,
+		port:       config.Port,
+		path:       strings.TrimSuffix(config.Path, "/"),
+		authHeader: fmt.Sprintf("Bearer %s", config.Secret),
+		log:        log.WithFields(log.Fields{"context": "admin"}),
+	}
+}
+
+// Start creates an HTTP server or attaches a handler to the existing one
+func (s *Server) Start(done chan (error)) error {
+	server, err := server.ForPort(strconv.Itoa(s.port))
+
+	if err != nil {
+		return err
+	}
+
+	s.server = server
+	s.server.Mux.Handle(s.path+"/", http.HandlerFunc(s.Handler))
+
+	s.log.Infof("Serve admin API at %s%s", s.server.Address(), s.path)
+
+	go func() {
+		if err := s.server.StartAndAnnounce("Admin API server"); err != nil {
+			if !s.server.Stopped() {
+				done <- fmt.Errorf("Admin API server at %s stopped: %v", s.server.Address(), err)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Shutdown stops the HTTP server
+func (s *Server) Shutdown() error {
+	if s.server != nil {
+		s.server.Shutdown() //nolint:errcheck
+	}
+
+	return nil
+}
+
+// Handler processes admin API requests:
+//   - GET <path>/streams — streams with the number of subscribers
+//   - GET <path>/sessions — sessions details (could be filtered by the "identifiers" param)
+//   - GET <path>/sessions/<id> — session details
+//
+// Collections are paginated via the "offset" and "limit" params.
+func (s *Server) Handler(w http.ResponseWriter, r *http.Request) {
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(s.authHeader)) != 1 {
+		s.writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	if r.Method != "GET" {
+		s.writeError(w, http.StatusMethodNotAllowed, fmt.Sprintf("Invalid request method: %s", r.Method))
+		return
+	}
+
+	route := strings.Trim(strings.TrimPrefix(r.URL.Path, s.path), "/")
+	parts := strings.SplitN(route, "/", 2)
+
+	switch {
+	case route == "streams":
+		s.handleStreams(w, r)
+	case route == "sessions":
+		s.handleSessions(w, r)
+	case len(parts) == 2 && parts[0] == "sessions":
+		s.handleSession(w, parts[1])
+	default:
+		s.writeError(w, http.StatusNotFound, "Not found")
+	}
+}
+
+func (s *Server) handleStreams(w http.ResponseWriter, r *http.Request) {
+	offset, limit, err := pageParams(r)
+
+	if err != nil {
+		s.writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	streams := s.inspector.Streams()
+	from, to := pageBounds(len(streams), offset, limit)
+
+	s.writeJSON(w, &Page{Total: len(streams), Offset: offset, Limit: limit, Items: streams[from:to]})
+}
+
+func (s *Server) handleSessions(w http.ResponseWriter, r *http.Request) {
+	offset, limit, err := pageParams(r)
+
+	if err != nil {
+		s.writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	if identifiers := r.URL.Query().Get("identifiers"); identifiers != "" {
+		sessions := s.inspector.SessionsInfoByIdentifiers(identifiers)
+		from, to := pageBounds(len(sessions), offset, limit)
+
+		s.writeJSON(w, &Page{Total: len(sessions), Offset: offset, Limit: limit, Items: sessions[from:to]})
+		return
+	}
+
+	sids := s.inspector.SessionIDs()
+	from, to := pageBounds(len(sids), offset, limit)
+
+	sessions := make([]*node.SessionInfo, 0, to-from)
+
+	for _, sid := range sids[from:to] {
+		// Session could be closed in the meantime
+		if info := s.inspector.SessionInfo(sid); info != nil {
+			sessions = append(sessions, info)
+		}
+	}
+
+	s.writeJSON(w, &Page{Total: len(sids), Offset: offset, Limit: limit, Items: sessions})
+}
+
+func (s *Server) handleSession(w http.ResponseWriter, sid string) {
+	info := s.inspector.SessionInfo(sid)
+
+	if info == nil {
+		s.writeError(w, http.StatusNotFound, fmt.Sprintf("Session not found: %s", sid))
+		return
+	}
+
+	s.writeJSON(w, info)
+}
+
+func (s *Server) writeJSON(w http.ResponseWriter, data interface{}) {
+	b, err := json.Marshal(data)
+
+	if err != nil {
+		s.log.Errorf("Failed to encode response: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(b) //nolint:errcheck
+}
+
+func (s *Server) writeError(w http.ResponseWriter, status int, msg string) {
+	s.log.Debugf("Request failed with status %d: %s", status, msg)
+
+	b, _ := json.Marshal(map[string]string{"error": msg})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(b) //nolint:errcheck
+}
+
+func pageParams(r *http.Request) (offset int, limit int, err error) {
+	query := r.URL.Query()
+
+	limit = defaultPageSize
+
+	if val := query.Get("offset"); val != "" {
+		offset, err = strconv.Atoi(val)
+
+		if err != nil || offset < 0 {
+			return 0, 0, fmt.Errorf("Invalid offset: %s", val)
+		}
+	}
+
+	if val := query.Get("limit"); val != "" {
+		limit, err = strconv.Atoi(val)
+
+		if err != nil || limit <= 0 || limit > maxPageSize {
+			return 0, 0, fmt.Errorf("Invalid limit (must be between 1 and %d): %s", maxPageSize, val)
+		}
+	}
+
+	return offset, limit, nil
+}
+
+func pageBounds(total int, offset int, limit int) (int, int) {
+	if offset > total {
+		offset = total
+	}
+
+	to := offset + limit
+
+	if to > total {
+		to = total
+	}
+
+	return offset, to
+}
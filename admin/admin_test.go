@@ -0,0 +1,149 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/anycable/anycable-go/hub"
+	"github.com/anycable/anycable-go/node"
+	"github.com/stretchr/testify/assert"
+)
+
+type testInspector struct {
+	sessions map[string]*node.SessionInfo
+}
+
+func (i *testInspector) Streams() []*hub.StreamInfo {
+	return []*hub.StreamInfo{
+		{Stream: "chat_1", Sessions: 2},
+		{Stream: "chat_2", Sessions: 1},
+		{Stream: "chat_3", Sessions: 1},
+	}
+}
+
+func (i *testInspector) SessionIDs() []string {
+	return []string{"s1", "s2", "s3"}
+}
+
+func (i *testInspector) SessionInfo(sid string) *node.SessionInfo {
+	return i.sessions[sid]
+}
+
+func (i *testInspector) SessionsInfoByIdentifiers(identifiers string) []*node.SessionInfo {
+	res := []*node.SessionInfo{}
+
+	for _, sid := range i.SessionIDs() {
+		if i.sessions[sid].Identifiers == identifiers {
+			res = append(res, i.sessions[sid])
+		}
+	}
+
+	return res
+}
+
+func TestHandler(t *testing.T) {
+	inspector := &testInspector{sessions: map[string]*node.SessionInfo{}}
+
+	for i, ids := range []string{"jack", "jack", "john"} {
+		sid := fmt.Sprintf("s%d", i+1)
+		inspector.sessions[sid] = &node.SessionInfo{
+			ID:          sid,
+			Identifiers: ids,
+			Channels:    map[string][]string{"chat": {"chat_1"}},
+			SendQueue:   i,
+		}
+	}
+
+	config := NewConfig()
+	config.Secret = "secret"
+	server := NewServer(inspector, &config)
+
+	request := func(method string, path string, auth string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(server.Handler).ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	t.Run("Rejects when authorization header is missing", func(t *testing.T) {
+		rr := request("GET", "/_admin/streams", "")
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("Rejects when authorization header is invalid", func(t *testing.T) {
+		rr := request("GET", "/_admin/streams", "Bearer secreto")
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("Rejects non-GET requests", func(t *testing.T) {
+		rr := request("POST", "/_admin/streams", "Bearer secret")
+		assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+	})
+
+	t.Run("Lists streams", func(t *testing.T) {
+		rr := request("GET", "/_admin/streams?offset=1&limit=1", "Bearer secret")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, `{"total":3,"offset":1,"limit":1,"items":[{"stream":"chat_2","sessions":1}]}`, rr.Body.String())
+	})
+
+	t.Run("Lists streams with offset out of range", func(t *testing.T) {
+		rr := request("GET", "/_admin/streams?offset=10", "Bearer secret")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, `{"total":3,"offset":10,"limit":100,"items":[]}`, rr.Body.String())
+	})
+
+	t.Run("Rejects invalid paging params", func(t *testing.T) {
+		rr := request("GET", "/_admin/streams?limit=100000", "Bearer secret")
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+
+		rr = request("GET", "/_admin/streams?offset=-1", "Bearer secret")
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	})
+
+	t.Run("Lists sessions", func(t *testing.T) {
+		rr := request("GET", "/_admin/sessions?limit=2", "Bearer secret")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"total":3`)
+		assert.Contains(t, rr.Body.String(), `"id":"s2"`)
+		assert.NotContains(t, rr.Body.String(), `"id":"s3"`)
+	})
+
+	t.Run("Lists sessions by identifiers", func(t *testing.T) {
+		rr := request("GET", "/_admin/sessions?identifiers=jack", "Bearer secret")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"total":2`)
+		assert.NotContains(t, rr.Body.String(), `"id":"s3"`)
+	})
+
+	t.Run("Shows session", func(t *testing.T) {
+		rr := request("GET", "/_admin/sessions/s3", "Bearer secret")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, `{"id":"s3","identifiers":"john","channels":{"chat":["chat_1"]},"cstate":null,"istate":null,"send_queue":2,"connected":false}`, rr.Body.String())
+	})
+
+	t.Run("Returns 404 for unknown session", func(t *testing.T) {
+		rr := request("GET", "/_admin/sessions/s42", "Bearer secret")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("Returns 404 for unknown path", func(t *testing.T) {
+		rr := request("GET", "/_admin/unknown", "Bearer secret")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
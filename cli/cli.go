@@ -10,8 +10,11 @@ import (
 	"strings"
 	"syscall"
 
+	"github.com/anycable/anycable-go/admin"
 	"github.com/anycable/anycable-go/common"
 	"github.com/anycable/anycable-go/config"
+	"github.com/anycable/anycable-go/enats"
+	"github.com/anycable/anycable-go/encoders"
 	"github.com/anycable/anycable-go/identity"
 	metricspkg "github.com/anycable/anycable-go/metrics"
 	"github.com/anycable/anycable-go/mrb"
@@ -34,12 +37,20 @@ import (
 type controllerFactory = func(*metricspkg.Metrics, *config.Config) (node.Controller, error)
 type disconnectorFactory = func(*node.Node, *config.Config) (node.Disconnector, error)
 type subscriberFactory = func(pubsub.Handler, *config.Config) (pubsub.Subscriber, error)
+type publisherFactory = func(*config.Config) (pubsub.Publisher, error)
 type websocketHandler = func(*node.Node, *config.Config) (http.Handler, error)
 
 type Shutdownable interface {
 	Shutdown() error
 }
 
+// shutdownFunc adapts a function to the Shutdownable interface
+type shutdownFunc func() error
+
+func (f shutdownFunc) Shutdown() error {
+	return f()
+}
+
 type Runner struct {
 	options []Option
 
@@ -50,6 +61,7 @@ type Runner struct {
 	controllerFactory       controllerFactory
 	disconnectorFactory     disconnectorFactory
 	subscriberFactory       subscriberFactory
+	publisherFactory        publisherFactory
 	websocketHandlerFactory websocketHandler
 
 	router *router.RouterController
@@ -97,6 +109,10 @@ func (r *Runner) checkAndSetDefaults() error {
 		r.disconnectorFactory = r.defaultDisconnector
 	}
 
+	if r.publisherFactory == nil {
+		r.publisherFactory = r.defaultPublisher
+	}
+
 	if r.websocketHandlerFactory == nil {
 		r.websocketHandlerFactory = r.defaultWebSocketHandler
 	}
@@ -135,7 +151,55 @@ func (r *Runner) Run() error {
 		return err
 	}
 
+	var natsService *enats.Service
+
+	if r.config.EmbedNATS {
+		natsService = enats.NewService(&r.config.EmbeddedNATS)
+
+		err = natsService.Start()
+		if err != nil {
+			return errorx.Decorate(err, "!!! Failed to start embedded NATS server !!!")
+		}
+
+		// Make NATS subscriber and publisher connect to the embedded server
+		r.config.NATSPubSub.Servers = natsService.ClientURL()
+	}
+
 	appNode := node.NewNode(controller, metrics, &r.config.App)
+
+	var broadcastHandler pubsub.Handler = appNode
+
+	if r.config.BroadcastDedupSize > 0 {
+		broadcastHandler = pubsub.NewDedupHandler(appNode, r.config.BroadcastDedupSize)
+	}
+
+	subscriber, err := r.subscriberFactory(broadcastHandler, r.config)
+	if err != nil {
+		return errorx.Decorate(err, "couldn't configure pub/sub")
+	}
+
+	if instrumented, ok := subscriber.(pubsub.Instrumentable); ok {
+		instrumented.SetMetrics(metrics)
+	}
+
+	// Subscribers tracking their connection state make the node not ready when disconnected
+	broadcastReady := func() bool { return true }
+
+	if stateful, ok := subscriber.(pubsub.StatefulSubscriber); ok {
+		broadcastReady = stateful.Ready
+		stateful.OnStateChange(appNode.NotifyBroadcastState)
+	}
+
+	if streamsSubscriber, ok := subscriber.(pubsub.StreamsSubscriber); ok {
+		appNode.OnStreamsChange(func(stream string, active bool) {
+			if active {
+				streamsSubscriber.SubscribeStream(stream)
+			} else {
+				streamsSubscriber.UnsubscribeStream(stream)
+			}
+		})
+	}
+
 	err = appNode.Start()
 
 	if err != nil {
@@ -150,9 +214,29 @@ func (r *Runner) Run() error {
 	go disconnector.Run() // nolint:errcheck
 	appNode.SetDisconnector(disconnector)
 
-	subscriber, err := r.subscriberFactory(appNode, r.config)
+	publisher, err := r.publisherFactory(r.config)
 	if err != nil {
-		return errorx.Decorate(err, "couldn't configure pub/sub")
+		return errorx.Decorate(err, "couldn't configure pub/sub publisher")
+	}
+
+	// Messages originated by the node are published in the background, so sessions are not blocked by a slow broker
+	_, noopPublisher := publisher.(*pubsub.NoopPublisher)
+
+	if !noopPublisher {
+		asyncPublisher := pubsub.NewAsyncPublisher(publisher, 0)
+		asyncPublisher.SetMetrics(metrics)
+
+		publisher = asyncPublisher
+	}
+
+	err = publisher.Start()
+	if err != nil {
+		return errorx.Decorate(err, "!!! Publisher failed !!!")
+	}
+
+	// Noop publisher means the node can't reach other nodes (e.g., HTTP adapter without a bus)
+	if !noopPublisher {
+		appNode.SetPublisher(publisher)
 	}
 
 	err = subscriber.Start(r.errChan)
@@ -176,23 +260,49 @@ func (r *Runner) Run() error {
 	}
 
 	for _, path := range r.config.Path {
-		wsServer.Mux.Handle(path, wsHandler)
+		wsServer.Mux.Handle(path, server.UnavailableWhen(appNode.Draining, wsHandler))
 		r.log.Infof("Handle WebSocket connections at %s%s", wsServer.Address(), path)
 	}
 
-	wsServer.Mux.Handle(r.config.HealthPath, http.HandlerFunc(server.HealthHandler))
+	wsServer.Mux.Handle(r.config.HealthPath, server.UnavailableWhen(appNode.Draining, http.HandlerFunc(server.HealthHandler)))
 	r.log.Infof("Handle health connections at %s%s", wsServer.Address(), r.config.HealthPath)
 
-	go r.startWSServer(wsServer)
-	go r.startMetrics(metrics)
+	if r.config.ReadinessPath != "" {
+		notReady := func() bool { return appNode.Draining() || !broadcastReady() }
+
+		wsServer.Mux.Handle(r.config.ReadinessPath, server.UnavailableWhen(notReady, http.HandlerFunc(server.HealthHandler)))
+		r.log.Infof("Handle readiness checks at %s%s", wsServer.Address(), r.config.ReadinessPath)
+	}
 
 	r.shutdownables = []Shutdownable{
+		// Drain connections first to keep delivering broadcasts to the remaining sessions
+		shutdownFunc(appNode.Drain),
 		metrics,
 		subscriber,
 		wsServer,
-		appNode,
 	}
 
+	if r.config.Admin.Enabled() {
+		adminServer := admin.NewServer(appNode, &r.config.Admin)
+
+		err = adminServer.Start(r.errChan)
+		if err != nil {
+			return errorx.Decorate(err, "!!! Failed to start admin API !!!")
+		}
+
+		r.shutdownables = append(r.shutdownables, adminServer)
+	}
+
+	r.shutdownables = append(r.shutdownables, appNode, publisher)
+
+	// Embedded NATS server goes last, since subscriber and publisher could depend on it
+	if natsService != nil {
+		r.shutdownables = append(r.shutdownables, natsService)
+	}
+
+	go r.startWSServer(wsServer)
+	go r.startMetrics(metrics)
+
 	r.announceGoPools()
 	r.setupSignalHandlers()
 
@@ -264,11 +374,16 @@ func (r *Runner) defaultDisconnector(n *node.Node, c *config.Config) (node.Disco
 	return node.NewDisconnectQueue(n, &c.DisconnectQueue), nil
 }
 
+func (r *Runner) defaultPublisher(c *config.Config) (pubsub.Publisher, error) {
+	return pubsub.NewPublisher(c.BroadcastAdapter, &c.Redis, &c.HTTPPubSub, &c.NATSPubSub)
+}
+
 func (r *Runner) defaultWebSocketHandler(n *node.Node, c *config.Config) (http.Handler, error) {
 	extractor := ws.HeadersExtractor{Headers: c.Headers, Cookies: c.Cookies}
 	return ws.WebsocketHandler(common.ActionCableProtocols(), extractor, &c.WS, func(wsc *websocket.Conn, info *ws.RequestInfo, callback func()) error {
 		wrappedConn := ws.NewConnection(wsc)
 		session := node.NewSession(n, wrappedConn, info.URL, info.Headers, info.UID)
+		session.SetEncoder(encoderForProtocol(wsc.Subprotocol()))
 
 		_, err := n.Authenticate(session)
 
@@ -280,6 +395,18 @@ func (r *Runner) defaultWebSocketHandler(n *node.Node, c *config.Config) (http.H
 	}), nil
 }
 
+// encoderForProtocol returns an encoder for the negotiated WebSocket subprotocol
+func encoderForProtocol(protocol string) encoders.Encoder {
+	switch protocol {
+	case common.ActionCableV1Msgpack:
+		return encoders.Msgpack{}
+	case common.ActionCableV1Protobuf:
+		return encoders.Protobuf{}
+	default:
+		return encoders.JSON{}
+	}
+}
+
 func (r *Runner) initMRuby() string {
 	if mrb.Supported() {
 		var mrbv string
@@ -3,6 +3,7 @@ package cli
 import (
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -10,3 +11,87 @@ func TestCliConfig(t *testing.T) {
 	_, err, _ := NewConfigFromCLI([]string{"-h"})
 	require.NoError(t, err)
 }
+
+func TestCliConfigSendQueuePolicy(t *testing.T) {
+	_, err, _ := NewConfigFromCLI([]string{"anycable-go", "--send_queue_policy=coalesce"})
+	require.NoError(t, err)
+
+	_, err, _ = NewConfigFromCLI([]string{"anycable-go", "--send_queue_policy=block"})
+	require.Error(t, err)
+}
+
+func TestCliConfigHTTPBroadcastBus(t *testing.T) {
+	c, err, _ := NewConfigFromCLI([]string{"anycable-go", "--broadcast_adapter=http", "--http_broadcast_bus=nats"})
+	require.NoError(t, err)
+	assert.Equal(t, "nats", c.HTTPPubSub.Bus)
+
+	_, err, _ = NewConfigFromCLI([]string{"anycable-go", "--broadcast_adapter=http", "--http_broadcast_bus=http"})
+	require.Error(t, err)
+}
+
+func TestCliConfigEmbeddedNATS(t *testing.T) {
+	c, err, _ := NewConfigFromCLI([]string{"anycable-go", "--embed_nats", "--enats_cluster=nats://0.0.0.0:6222", "--enats_cluster_routes=nats://anycable-1:6222,nats://anycable-2:6222"})
+	require.NoError(t, err)
+
+	assert.True(t, c.EmbedNATS)
+	assert.Equal(t, "nats://127.0.0.1:4222", c.EmbeddedNATS.ServiceAddr)
+	assert.Equal(t, "nats://0.0.0.0:6222", c.EmbeddedNATS.ClusterAddr)
+	assert.Equal(t, []string{"nats://anycable-1:6222", "nats://anycable-2:6222"}, c.EmbeddedNATS.Routes)
+}
+
+func TestCliConfigEmbeddedNATSJetStream(t *testing.T) {
+	c, err, _ := NewConfigFromCLI([]string{"anycable-go", "--embed_nats", "--broadcast_adapter=http,jetstream", "--enats_jetstream_store_dir=/tmp/enats"})
+	require.NoError(t, err)
+
+	assert.True(t, c.EmbeddedNATS.JetStream)
+	assert.Equal(t, "/tmp/enats", c.EmbeddedNATS.StoreDir)
+
+	c, err, _ = NewConfigFromCLI([]string{"anycable-go", "--embed_nats", "--broadcast_adapter=http", "--http_broadcast_bus=jetstream"})
+	require.NoError(t, err)
+
+	assert.True(t, c.EmbeddedNATS.JetStream)
+
+	c, err, _ = NewConfigFromCLI([]string{"anycable-go", "--embed_nats", "--broadcast_adapter=nats"})
+	require.NoError(t, err)
+
+	assert.False(t, c.EmbeddedNATS.JetStream)
+}
+
+func TestCliConfigRedisReconnect(t *testing.T) {
+	c, err, _ := NewConfigFromCLI([]string{"anycable-go", "--redis_max_reconnect_attempts=0", "--redis_max_reconnect_delay=10"})
+	require.NoError(t, err)
+	assert.Equal(t, 0, c.Redis.MaxReconnectAttempts)
+	assert.Equal(t, 10, c.Redis.MaxReconnectDelay)
+
+	_, err, _ = NewConfigFromCLI([]string{"anycable-go", "--redis_max_reconnect_delay=0"})
+	require.Error(t, err)
+}
+
+func TestCliConfigRedisStreamMaxLen(t *testing.T) {
+	c, err, _ := NewConfigFromCLI([]string{"anycable-go", "--redis_stream_max_len=100"})
+	require.NoError(t, err)
+	assert.Equal(t, 100, c.Redis.StreamMaxLen)
+
+	_, err, _ = NewConfigFromCLI([]string{"anycable-go", "--redis_stream_max_len=0"})
+	require.Error(t, err)
+
+	_, err, _ = NewConfigFromCLI([]string{"anycable-go", "--redis_stream_max_len=-1"})
+	require.Error(t, err)
+}
+
+func TestCliConfigRedisSecurity(t *testing.T) {
+	c, err, _ := NewConfigFromCLI([]string{"anycable-go", "--redis_username=anycable", "--redis_password=secret", "--redis_tls_cert=client.crt", "--redis_tls_key=client.key", "--redis_tls_ca=ca.crt", "--redis_cluster_nodes=redis-1:6379,redis-2:6379"})
+	require.NoError(t, err)
+	assert.Equal(t, "anycable", c.Redis.Username)
+	assert.Equal(t, "secret", c.Redis.Password)
+	assert.Equal(t, "client.crt", c.Redis.TLSCert)
+	assert.Equal(t, "client.key", c.Redis.TLSKey)
+	assert.Equal(t, "ca.crt", c.Redis.TLSCA)
+	assert.Equal(t, "redis-1:6379,redis-2:6379", c.Redis.ClusterNodes)
+
+	_, err, _ = NewConfigFromCLI([]string{"anycable-go", "--redis_tls_cert=client.crt"})
+	require.Error(t, err)
+
+	_, err, _ = NewConfigFromCLI([]string{"anycable-go", "--redis_sentinels=sentinel:26379", "--redis_cluster_nodes=redis-1:6379"})
+	require.Error(t, err)
+}
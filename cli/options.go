@@ -6,6 +6,8 @@ import (
 	"strings"
 
 	"github.com/anycable/anycable-go/config"
+	"github.com/anycable/anycable-go/node"
+	"github.com/anycable/anycable-go/pubsub"
 	"github.com/anycable/anycable-go/version"
 	"github.com/urfave/cli/v2"
 )
@@ -15,7 +17,7 @@ import (
 func NewConfigFromCLI(args []string) (*config.Config, error, bool) {
 	c := config.NewConfig()
 
-	var path, headers, cookieFilter string
+	var path, headers, cookieFilter, enatsRoutes string
 	var helpOrVersionWereShown bool = true
 
 	// Print raw version without prefix
@@ -30,6 +32,7 @@ func NewConfigFromCLI(args []string) (*config.Config, error, bool) {
 	flags = append(flags, redisCLIFlags(&c)...)
 	flags = append(flags, httpBroadcastCLIFlags(&c)...)
 	flags = append(flags, natsCLIFlags(&c)...)
+	flags = append(flags, embeddedNATSCLIFlags(&c, &enatsRoutes)...)
 	flags = append(flags, rpcCLIFlags(&c, &headers, &cookieFilter)...)
 	flags = append(flags, disconnectorCLIFlags(&c)...)
 	flags = append(flags, logCLIFlags(&c)...)
@@ -38,6 +41,7 @@ func NewConfigFromCLI(args []string) (*config.Config, error, bool) {
 	flags = append(flags, pingCLIFlags(&c)...)
 	flags = append(flags, jwtCLIFlags(&c)...)
 	flags = append(flags, signedStreamsCLIFlags(&c)...)
+	flags = append(flags, adminCLIFlags(&c)...)
 
 	app := &cli.App{
 		Name:            "anycable-go",
@@ -69,6 +73,43 @@ func NewConfigFromCLI(args []string) (*config.Config, error, bool) {
 		c.Path = strings.Split(path, " ")
 	}
 
+	if !node.IsSendQueuePolicy(c.App.SendQueuePolicy) {
+		return &config.Config{}, fmt.Errorf("Unknown send queue policy: %s", c.App.SendQueuePolicy), false
+	}
+
+	if c.App.SendQueueSize <= 0 {
+		return &config.Config{}, fmt.Errorf("Send queue size must be positive: %d", c.App.SendQueueSize), false
+	}
+
+	if c.Redis.StreamMaxLen <= 0 {
+		return &config.Config{}, fmt.Errorf("Redis stream max length must be positive: %d", c.Redis.StreamMaxLen), false
+	}
+
+	if c.Redis.MaxReconnectDelay <= 0 {
+		return &config.Config{}, fmt.Errorf("Redis max reconnect delay must be positive: %d", c.Redis.MaxReconnectDelay), false
+	}
+
+	if c.Redis.Sentinels != "" && c.Redis.ClusterNodes != "" {
+		return &config.Config{}, fmt.Errorf("Redis sentinels and cluster nodes couldn't be used together"), false
+	}
+
+	if (c.Redis.TLSCert == "") != (c.Redis.TLSKey == "") {
+		return &config.Config{}, fmt.Errorf("Both Redis TLS client certificate and key must be specified"), false
+	}
+
+	if c.HTTPPubSub.Bus != "" && !pubsub.IsBusAdapter(c.HTTPPubSub.Bus) {
+		return &config.Config{}, fmt.Errorf("Unsupported HTTP broadcast bus: %s", c.HTTPPubSub.Bus), false
+	}
+
+	if enatsRoutes != "" {
+		c.EmbeddedNATS.Routes = strings.Split(enatsRoutes, ",")
+	}
+
+	// JetStream broadcast adapter requires JetStream to be enabled in the embedded server
+	if c.EmbedNATS && usesAdapter(&c, "jetstream") {
+		c.EmbeddedNATS.JetStream = true
+	}
+
 	c.Headers = strings.Split(strings.ToLower(headers), ",")
 
 	if len(cookieFilter) > 0 {
@@ -84,6 +125,10 @@ func NewConfigFromCLI(args []string) (*config.Config, error, bool) {
 		c.Metrics.Port = c.Port
 	}
 
+	if c.Admin.Port == 0 {
+		c.Admin.Port = c.Port
+	}
+
 	if c.Metrics.LogInterval > 0 {
 		fmt.Println(`DEPRECATION WARNING: metrics_log_interval option is deprecated
 and will be deleted in the next major release of anycable-go.
@@ -106,6 +151,7 @@ const (
 	redisCategoryDescription         = "REDIS:"
 	httpBroadcastCategoryDescription = "HTTP BROADCAST:"
 	natsCategoryDescription          = "NATS:"
+	embeddedNATSCategoryDescription  = "EMBEDDED NATS:"
 	rpcCategoryDescription           = "RPC:"
 	disconnectorCategoryDescription  = "DISCONNECTOR:"
 	logCategoryDescription           = "LOG:"
@@ -114,6 +160,7 @@ const (
 	pingCategoryDescription          = "PING:"
 	jwtCategoryDescription           = "JWT:"
 	signedStreamsCategoryDescription = "SIGNED STREAMS:"
+	adminCategoryDescription         = "ADMIN API:"
 
 	envPrefix = "ANYCABLE_"
 )
@@ -159,6 +206,26 @@ func serverCLIFlags(c *config.Config, path *string) []cli.Flag {
 			Usage:       "HTTP health endpoint path",
 			Destination: &c.HealthPath,
 		},
+
+		&cli.StringFlag{
+			Name:        "readiness-path",
+			Usage:       "HTTP readiness endpoint path (responds with 503 while broadcasts are not being received), disabled if empty",
+			Destination: &c.ReadinessPath,
+		},
+
+		&cli.IntFlag{
+			Name:        "session_restore_ttl",
+			Usage:       "How long to keep disconnected sessions to restore them without RPC calls (in seconds, 0 – disable restoring)",
+			Value:       c.App.SessionRestoreTTL,
+			Destination: &c.App.SessionRestoreTTL,
+		},
+
+		&cli.IntFlag{
+			Name:        "whisper_rate_limit",
+			Usage:       "The max number of whispers per second per session (0 – no limit)",
+			Value:       c.App.WhisperRateLimit,
+			Destination: &c.App.WhisperRateLimit,
+		},
 	})
 }
 
@@ -184,17 +251,50 @@ func broadcastCLIFlags(c *config.Config) []cli.Flag {
 	return withDefaults(broadcastCategoryDescription, []cli.Flag{
 		&cli.StringFlag{
 			Name:        "broadcast_adapter",
-			Usage:       "Broadcasting adapter to use (redis, http or nats)",
+			Usage:       "Broadcasting adapter to use (redis, redisx, http, nats or jetstream). You can specify multiple adapters using comma as separator",
 			Value:       c.BroadcastAdapter,
 			Destination: &c.BroadcastAdapter,
 		},
 
+		&cli.BoolFlag{
+			Name:        "broadcast_degraded_notice",
+			Usage:       "Notify clients when broadcasts delivery is degraded (e.g., pub/sub connection is lost) and restored",
+			Destination: &c.App.BroadcastDegradedNotice,
+		},
+
+		&cli.IntFlag{
+			Name:        "broadcast_dedup_size",
+			Usage:       "The number of recent broadcast IDs to remember to skip duplicates (0 – disable deduplication)",
+			Destination: &c.BroadcastDedupSize,
+		},
+
 		&cli.IntFlag{
 			Name:        "hub_gopool_size",
 			Usage:       "The size of the goroutines pool to broadcast messages",
 			Value:       c.App.HubGopoolSize,
 			Destination: &c.App.HubGopoolSize,
 		},
+
+		&cli.IntFlag{
+			Name:        "hub_shards",
+			Usage:       "The number of hub shards (to reduce locks contention)",
+			Value:       c.App.HubShards,
+			Destination: &c.App.HubShards,
+		},
+
+		&cli.IntFlag{
+			Name:        "history_limit",
+			Usage:       "The max number of messages to keep in a stream history (0 – disable history)",
+			Value:       c.App.HistoryLimit,
+			Destination: &c.App.HistoryLimit,
+		},
+
+		&cli.IntFlag{
+			Name:        "history_ttl",
+			Usage:       "How long to keep messages in a stream history (in seconds)",
+			Value:       c.App.HistoryTTL,
+			Destination: &c.App.HistoryTTL,
+		},
 	})
 }
 
@@ -215,6 +315,18 @@ func redisCLIFlags(c *config.Config) []cli.Flag {
 			Destination: &c.Redis.Channel,
 		},
 
+		&cli.StringFlag{
+			Name:        "redis_username",
+			Usage:       "Redis ACL username (takes precedence over the URL credentials)",
+			Destination: &c.Redis.Username,
+		},
+
+		&cli.StringFlag{
+			Name:        "redis_password",
+			Usage:       "Redis password (takes precedence over the URL credentials)",
+			Destination: &c.Redis.Password,
+		},
+
 		&cli.StringFlag{
 			Name:        "redis_sentinels",
 			Usage:       "Comma separated list of sentinel hosts, format: 'hostname:port,..'",
@@ -241,6 +353,65 @@ func redisCLIFlags(c *config.Config) []cli.Flag {
 			Value:       c.Redis.TLSVerify,
 			Destination: &c.Redis.TLSVerify,
 		},
+
+		&cli.StringFlag{
+			Name:        "redis_tls_cert",
+			Usage:       "Path to the Redis TLS client certificate file (for mutual TLS)",
+			Destination: &c.Redis.TLSCert,
+		},
+
+		&cli.StringFlag{
+			Name:        "redis_tls_key",
+			Usage:       "Path to the Redis TLS client key file (for mutual TLS)",
+			Destination: &c.Redis.TLSKey,
+		},
+
+		&cli.StringFlag{
+			Name:        "redis_tls_ca",
+			Usage:       "Path to the CA certificate file to verify Redis server TLS certificate with",
+			Destination: &c.Redis.TLSCA,
+		},
+
+		&cli.StringFlag{
+			Name:        "redis_cluster_nodes",
+			Usage:       "Comma separated list of Redis Cluster nodes to discover the cluster from, format: 'hostname:port,..'",
+			Destination: &c.Redis.ClusterNodes,
+		},
+
+		&cli.BoolFlag{
+			Name:        "redis_stream_channels",
+			Usage:       "Use a separate Redis channel per stream (and subscribe only to streams with local subscribers)",
+			Value:       c.Redis.StreamChannels,
+			Destination: &c.Redis.StreamChannels,
+		},
+
+		&cli.BoolFlag{
+			Name:        "redis_sharded",
+			Usage:       "Use sharded pub/sub (SSUBSCRIBE/SPUBLISH) for stream channels (requires Redis 7+, implies --redis_stream_channels)",
+			Value:       c.Redis.Sharded,
+			Destination: &c.Redis.Sharded,
+		},
+
+		&cli.IntFlag{
+			Name:        "redis_max_reconnect_attempts",
+			Usage:       "The max number of Redis reconnect attempts before exiting (0 – reconnect forever)",
+			Value:       c.Redis.MaxReconnectAttempts,
+			Destination: &c.Redis.MaxReconnectAttempts,
+		},
+
+		&cli.IntFlag{
+			Name:        "redis_max_reconnect_delay",
+			Usage:       "The max delay between Redis reconnect attempts when reconnecting forever (in seconds)",
+			Value:       c.Redis.MaxReconnectDelay,
+			Destination: &c.Redis.MaxReconnectDelay,
+		},
+
+		&cli.IntFlag{
+			Name:        "redis_stream_max_len",
+			Usage:       "The approximate max number of entries to keep in the Redis stream (redisx adapter)",
+			Value:       c.Redis.StreamMaxLen,
+			Destination: &c.Redis.StreamMaxLen,
+		},
 	})
 }
 
@@ -266,6 +437,12 @@ func httpBroadcastCLIFlags(c *config.Config) []cli.Flag {
 			Usage:       "HTTP pub/sub authorization secret",
 			Destination: &c.HTTPPubSub.Secret,
 		},
+
+		&cli.StringFlag{
+			Name:        "http_broadcast_bus",
+			Usage:       "Pub/sub adapter to re-publish HTTP broadcasts to, so all nodes receive them (redis, redisx, nats or jetstream)",
+			Destination: &c.HTTPPubSub.Bus,
+		},
 	})
 }
 
@@ -286,6 +463,26 @@ func natsCLIFlags(c *config.Config) []cli.Flag {
 			Destination: &c.NATSPubSub.Channel,
 		},
 
+		&cli.BoolFlag{
+			Name:        "nats_stream_subjects",
+			Usage:       "Use a separate NATS subject per stream (and subscribe only to streams with local subscribers)",
+			Destination: &c.NATSPubSub.StreamSubjects,
+		},
+
+		&cli.StringFlag{
+			Name:        "nats_jetstream_name",
+			Usage:       "JetStream stream name for broadcasts (jetstream adapter)",
+			Value:       c.NATSPubSub.JetStreamName,
+			Destination: &c.NATSPubSub.JetStreamName,
+		},
+
+		&cli.Int64Flag{
+			Name:        "nats_jetstream_max_msgs",
+			Usage:       "The max number of messages to keep in the JetStream stream (jetstream adapter)",
+			Value:       c.NATSPubSub.JetStreamMaxMsgs,
+			Destination: &c.NATSPubSub.JetStreamMaxMsgs,
+		},
+
 		&cli.BoolFlag{
 			Name:        "nats_dont_randomize_servers",
 			Usage:       "Pass this option to disable NATS servers randomization during (re-)connect",
@@ -294,6 +491,73 @@ func natsCLIFlags(c *config.Config) []cli.Flag {
 
 }
 
+// embeddedNATSCLIFlags returns CLI flags for the embedded NATS server
+func embeddedNATSCLIFlags(c *config.Config, routes *string) []cli.Flag {
+	return withDefaults(embeddedNATSCategoryDescription, []cli.Flag{
+		&cli.BoolFlag{
+			Name:        "embed_nats",
+			Usage:       "Run embedded NATS server (NATS broadcast adapter connects to it automatically)",
+			Destination: &c.EmbedNATS,
+		},
+
+		&cli.StringFlag{
+			Name:        "enats_addr",
+			Usage:       "Embedded NATS server service address",
+			Value:       c.EmbeddedNATS.ServiceAddr,
+			Destination: &c.EmbeddedNATS.ServiceAddr,
+		},
+
+		&cli.StringFlag{
+			Name:        "enats_cluster",
+			Usage:       "Embedded NATS server cluster address (clustering is disabled if empty)",
+			Destination: &c.EmbeddedNATS.ClusterAddr,
+		},
+
+		&cli.StringFlag{
+			Name:        "enats_cluster_name",
+			Usage:       "Embedded NATS server cluster name",
+			Value:       c.EmbeddedNATS.ClusterName,
+			Destination: &c.EmbeddedNATS.ClusterName,
+		},
+
+		&cli.StringFlag{
+			Name:        "enats_cluster_routes",
+			Usage:       "Comma separated list of the cluster peers addresses (e.g., nats://anycable-1:6222,nats://anycable-2:6222)",
+			Destination: routes,
+		},
+
+		&cli.StringFlag{
+			Name:        "enats_server_name",
+			Usage:       "Embedded NATS server name (must be unique within the cluster, the hostname is used for JetStream clusters by default)",
+			Destination: &c.EmbeddedNATS.ServerName,
+		},
+
+		&cli.BoolFlag{
+			Name:        "enats_jetstream",
+			Usage:       "Enable JetStream in the embedded NATS server (enabled automatically for the jetstream broadcast adapter)",
+			Destination: &c.EmbeddedNATS.JetStream,
+		},
+
+		&cli.StringFlag{
+			Name:        "enats_jetstream_store_dir",
+			Usage:       "Embedded NATS server JetStream storage directory (a temporary directory is used by default)",
+			Destination: &c.EmbeddedNATS.StoreDir,
+		},
+
+		&cli.BoolFlag{
+			Name:        "enats_debug",
+			Usage:       "Enable embedded NATS server debug logging",
+			Destination: &c.EmbeddedNATS.Debug,
+		},
+
+		&cli.BoolFlag{
+			Name:        "enats_trace",
+			Usage:       "Enable embedded NATS server protocol trace logging",
+			Destination: &c.EmbeddedNATS.Trace,
+		},
+	})
+}
+
 // rpcCLIFlags returns CLI flags for RPC
 func rpcCLIFlags(c *config.Config, headers, cookieFilter *string) []cli.Flag {
 	return withDefaults(rpcCategoryDescription, []cli.Flag{
@@ -363,6 +627,20 @@ func disconnectorCLIFlags(c *config.Config) []cli.Flag {
 			Destination: &c.DisconnectQueue.ShutdownTimeout,
 		},
 
+		&cli.IntFlag{
+			Name:        "shutdown_drain_period",
+			Usage:       "The period to gradually close connections on shutdown (in seconds, 0 – close all connections at once)",
+			Value:       c.App.ShutdownDrainPeriod,
+			Destination: &c.App.ShutdownDrainPeriod,
+		},
+
+		&cli.IntFlag{
+			Name:        "shutdown_reconnect_delay",
+			Usage:       "The max reconnect delay suggested to clients on shutdown (in milliseconds, 0 – no delay)",
+			Value:       c.App.ShutdownReconnectDelay,
+			Destination: &c.App.ShutdownReconnectDelay,
+		},
+
 		&cli.BoolFlag{
 			Name:        "disable_disconnect",
 			Usage:       "Disable calling Disconnect callback",
@@ -488,6 +766,20 @@ func wsCLIFlags(c *config.Config) []cli.Flag {
 			Usage:       `Accept requests only from specified origins, e.g., "www.example.com,*example.io". No check is performed if empty`,
 			Destination: &c.WS.AllowedOrigins,
 		},
+
+		&cli.IntFlag{
+			Name:        "send_queue_size",
+			Usage:       "The max number of pending outgoing messages per connection",
+			Value:       c.App.SendQueueSize,
+			Destination: &c.App.SendQueueSize,
+		},
+
+		&cli.StringFlag{
+			Name:        "send_queue_policy",
+			Usage:       "What to do when a connection send queue is full (disconnect, drop_oldest, drop_newest or coalesce)",
+			Value:       c.App.SendQueuePolicy,
+			Destination: &c.App.SendQueuePolicy,
+		},
 	})
 }
 
@@ -611,3 +903,43 @@ func nameToEnvVarName(name string) string {
 
 	return envPrefix + strings.Join(set, "_")
 }
+
+// adminCLIFlags returns CLI flags for admin API
+func adminCLIFlags(c *config.Config) []cli.Flag {
+	return withDefaults(adminCategoryDescription, []cli.Flag{
+		&cli.StringFlag{
+			Name:        "admin_secret",
+			Usage:       "A secret token to authorize admin API requests (admin API is disabled if empty)",
+			Destination: &c.Admin.Secret,
+		},
+
+		&cli.StringFlag{
+			Name:        "admin_path",
+			Usage:       "Admin API path prefix",
+			Value:       c.Admin.Path,
+			Destination: &c.Admin.Path,
+		},
+
+		&cli.IntFlag{
+			Name:        "admin_port",
+			Usage:       "Admin API port (the main server port is used by default)",
+			Value:       c.Admin.Port,
+			Destination: &c.Admin.Port,
+		},
+	})
+}
+
+// usesAdapter returns true if the broadcast adapter (or the HTTP broadcast bus) is configured
+func usesAdapter(c *config.Config, adapter string) bool {
+	if c.HTTPPubSub.Bus == adapter {
+		return true
+	}
+
+	for _, name := range strings.Split(c.BroadcastAdapter, ",") {
+		if strings.TrimSpace(name) == adapter {
+			return true
+		}
+	}
+
+	return false
+}
@@ -55,3 +55,14 @@ func WithDefaultSubscriber() Option {
 		return pubsub.NewSubscriber(h, c.BroadcastAdapter, &c.Redis, &c.HTTPPubSub, &c.NATSPubSub)
 	})
 }
+
+// WithPublisher is an Option to set Runner publisher (used to share broadcasts and the node state with other nodes)
+func WithPublisher(fn publisherFactory) Option {
+	return func(r *Runner) error {
+		if r.publisherFactory != nil {
+			return errorx.IllegalArgument.New("Publisher has been already assigned")
+		}
+		r.publisherFactory = fn
+		return nil
+	}
+}
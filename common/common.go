@@ -14,22 +14,34 @@ const (
 )
 
 const (
-	ActionCableV1JSON = "actioncable-v1-json"
+	ActionCableV1JSON     = "actioncable-v1-json"
+	ActionCableV1Msgpack  = "actioncable-v1-msgpack"
+	ActionCableV1Protobuf = "actioncable-v1-protobuf"
 )
 
 func ActionCableProtocols() []string {
-	return []string{ActionCableV1JSON}
+	return []string{ActionCableV1JSON, ActionCableV1Msgpack, ActionCableV1Protobuf}
 }
 
 // Outgoing message types (according to Action Cable protocol)
 const (
-	WelcomeType    = "welcome"
-	PingType       = "ping"
-	DisconnectType = "disconnect"
-	ConfirmedType  = "confirm_subscription"
-	RejectedType   = "reject_subscription"
+	WelcomeType = "welcome"
+	// Sent instead of welcome when the session has been restored
+	SessionRestoredType = "session_restored"
+	PingType            = "ping"
+	DisconnectType      = "disconnect"
+	ConfirmedType       = "confirm_subscription"
+	RejectedType        = "reject_subscription"
+	// History replay results
+	HistoryConfirmedType = "confirm_history"
+	HistoryRejectedType  = "reject_history"
+	// Presence events and query results
+	PresenceType = "presence"
 	// Not supported by Action Cable currently
 	UnsubscribedType = "unsubscribed"
+	// Sent when broadcasts delivery is interrupted (e.g., pub/sub connection is lost) and restored
+	BroadcastDegradedType = "broadcast_degraded"
+	BroadcastRestoredType = "broadcast_restored"
 )
 
 // Disconnect reasons
@@ -184,15 +196,74 @@ func (c *CommandResult) ToCallResult() *CallResult {
 
 // Message represents incoming client message
 type Message struct {
-	Command    string      `json:"command"`
-	Identifier string      `json:"identifier"`
-	Data       interface{} `json:"data,omitempty"`
+	Command    string          `json:"command"`
+	Identifier string          `json:"identifier"`
+	Data       interface{}     `json:"data,omitempty"`
+	History    *HistoryRequest `json:"history,omitempty"`
+	Presence   *PresenceRecord `json:"presence,omitempty"`
+}
+
+// HistoryPosition represents the last received message position in a stream
+type HistoryPosition struct {
+	Epoch  string `json:"epoch"`
+	Offset uint64 `json:"offset"`
+}
+
+// HistoryRequest represents a client request to replay missed stream messages.
+// Streams contains positions for particular streams; Since (Unix timestamp in seconds)
+// is used for streams with no position specified.
+type HistoryRequest struct {
+	Since   int64                      `json:"since,omitempty"`
+	Streams map[string]HistoryPosition `json:"streams,omitempty"`
+}
+
+// Presence event types
+const (
+	PresenceJoinType  = "join"
+	PresenceLeaveType = "leave"
+	PresenceInfoType  = "info"
+	// Sent by nodes periodically to refresh the members they own
+	PresenceSyncType = "sync"
+)
+
+// PresenceRecord represents a presence set member
+type PresenceRecord struct {
+	Stream string      `json:"stream,omitempty"`
+	ID     string      `json:"id"`
+	Info   interface{} `json:"info,omitempty"`
+}
+
+// PresenceEvent is sent to stream subscribers when a member joins or leaves
+type PresenceEvent struct {
+	Type string      `json:"type"`
+	ID   string      `json:"id"`
+	Info interface{} `json:"info,omitempty"`
+}
+
+// PresenceInfo is sent in response to a presence query
+type PresenceInfo struct {
+	Type    string            `json:"type"`
+	Total   int               `json:"total"`
+	Records []*PresenceRecord `json:"records"`
 }
 
 // StreamMessage represents a pub/sub message to be sent to stream
 type StreamMessage struct {
 	Stream string `json:"stream"`
 	Data   string `json:"data"`
+	// Offset and Epoch are assigned by the hub when the history is enabled
+	// (and thus must not be provided by publishers)
+	Offset uint64 `json:"-"`
+	Epoch  string `json:"-"`
+	// ID of the session which must not receive the message (e.g., the originating session)
+	ExcludeSocket string `json:"exclude_socket,omitempty"`
+	// Could be set for broadcasts returned by controllers to exclude the caller session
+	// (the node sets ExcludeSocket to the caller's session ID)
+	ToOthers bool `json:"-"`
+	// ID of the node which originated the message (set for messages published by nodes themselves)
+	Node string `json:"node,omitempty"`
+	// Ephemeral messages (e.g., whispers) are not stored in the streams history
+	Ephemeral bool `json:"ephemeral,omitempty"`
 }
 
 func (sm *StreamMessage) ToReplyFor(identifier string) *Reply {
@@ -210,19 +281,72 @@ func (sm *StreamMessage) ToReplyFor(identifier string) *Reply {
 	return &Reply{
 		Identifier: identifier,
 		Message:    msg,
+		StreamID:   sm.streamID(),
+		Offset:     sm.Offset,
+		Epoch:      sm.Epoch,
+		Stream:     sm.Stream,
 	}
 }
 
+// streamID returns a stream name only for messages with a position,
+// so clients could use it to request history
+func (sm *StreamMessage) streamID() string {
+	if sm.Offset == 0 {
+		return ""
+	}
+
+	return sm.Stream
+}
+
 // RemoteCommandMessage represents a pub/sub message with a remote command (e.g., disconnect)
 type RemoteCommandMessage struct {
 	Command string          `json:"command,omitempty"`
 	Payload json.RawMessage `json:"payload,omitempty"`
 }
 
-// RemoteDisconnectMessage contains information required to disconnect a session
+// RemoteDisconnectMessage contains information required to disconnect sessions.
+// Sessions must match all the specified selectors (identifiers, session ID, stream and attributes).
 type RemoteDisconnectMessage struct {
+	Identifier string `json:"identifier,omitempty"`
+	// Session ID (to disconnect a particular device)
+	SessionID string `json:"sid,omitempty"`
+	// Disconnect all sessions subscribed to the stream
+	Stream string `json:"stream,omitempty"`
+	// Top-level identifiers attributes (e.g., {"user_id":"42"})
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Reconnect  bool              `json:"reconnect"`
+	// If set, nodes publish the number of closed sessions (via the "disconnect_ack" command)
+	AckID string `json:"ack_id,omitempty"`
+}
+
+// RemoteDisconnectAckMessage contains the number of sessions disconnected by the node
+type RemoteDisconnectAckMessage struct {
+	AckID    string `json:"ack_id"`
+	Node     string `json:"node"`
+	Sessions int    `json:"sessions"`
+}
+
+// RemoteStreamStartMessage contains information required to subscribe sessions
+// (with the specified identifiers and subscribed to the channel) to a stream
+type RemoteStreamStartMessage struct {
+	Identifier string `json:"identifier"`
+	Channel    string `json:"channel"`
+	Stream     string `json:"stream"`
+}
+
+// RemoteStreamStopMessage contains information required to unsubscribe sessions
+// (with the specified identifiers) from a stream or from the channel (if stream is empty)
+type RemoteStreamStopMessage struct {
 	Identifier string `json:"identifier"`
-	Reconnect  bool   `json:"reconnect"`
+	Channel    string `json:"channel"`
+	Stream     string `json:"stream,omitempty"`
+}
+
+// RemotePresenceMessage contains presence changes (or a snapshot of all members) of another node
+type RemotePresenceMessage struct {
+	Node    string            `json:"node"`
+	Event   string            `json:"event"`
+	Records []*PresenceRecord `json:"records"`
 }
 
 // PingMessage represents a server ping
@@ -240,6 +364,8 @@ type DisconnectMessage struct {
 	Type      string `json:"type"`
 	Reason    string `json:"reason"`
 	Reconnect bool   `json:"reconnect"`
+	// Suggested delay before reconnecting (in milliseconds)
+	ReconnectDelay int `json:"reconnect_delay,omitempty"`
 }
 
 func (d *DisconnectMessage) GetType() string {
@@ -257,6 +383,13 @@ type Reply struct {
 	Message    interface{} `json:"message,omitempty"`
 	Reason     string      `json:"reason,omitempty"`
 	Reconnect  bool        `json:"reconnect,omitempty"`
+	StreamID   string      `json:"stream_id,omitempty"`
+	Offset     uint64      `json:"offset,omitempty"`
+	Epoch      string      `json:"epoch,omitempty"`
+	// Token to restore the session after reconnecting
+	RestoreToken string `json:"restore_token,omitempty"`
+	// The stream the message has been broadcasted to (not sent to clients)
+	Stream string `json:"-"`
 }
 
 func (r *Reply) GetType() string {
@@ -289,6 +422,46 @@ func PubSubMessageFromJSON(raw []byte) (interface{}, error) {
 		return dmsg, nil
 	}
 
+	if rmsg.Command == "disconnect_ack" {
+		amsg := RemoteDisconnectAckMessage{}
+
+		if err := json.Unmarshal(rmsg.Payload, &amsg); err != nil {
+			return nil, err
+		}
+
+		return amsg, nil
+	}
+
+	if rmsg.Command == "stream_start" {
+		smsg := RemoteStreamStartMessage{}
+
+		if err := json.Unmarshal(rmsg.Payload, &smsg); err != nil {
+			return nil, err
+		}
+
+		return smsg, nil
+	}
+
+	if rmsg.Command == "stream_stop" {
+		smsg := RemoteStreamStopMessage{}
+
+		if err := json.Unmarshal(rmsg.Payload, &smsg); err != nil {
+			return nil, err
+		}
+
+		return smsg, nil
+	}
+
+	if rmsg.Command == "presence" {
+		pmsg := RemotePresenceMessage{}
+
+		if err := json.Unmarshal(rmsg.Payload, &pmsg); err != nil {
+			return nil, err
+		}
+
+		return pmsg, nil
+	}
+
 	return nil, fmt.Errorf("Unknown message: %s", raw)
 }
 
@@ -297,6 +470,11 @@ func ConfirmationMessage(identifier string) string {
 	return string(toJSON(Reply{Identifier: identifier, Type: ConfirmedType}))
 }
 
+// UnsubscribedMessage returns a message notifying the client that the subscription has been terminated by the server
+func UnsubscribedMessage(identifier string) *Reply {
+	return &Reply{Identifier: identifier, Type: UnsubscribedType}
+}
+
 // RejectionMessage returns a subscription rejection message for a specified identifier
 func RejectionMessage(identifier string) string {
 	return string(toJSON(Reply{Identifier: identifier, Type: RejectedType}))
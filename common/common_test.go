@@ -68,6 +68,58 @@ func TestPubSubMessageFromJSON(t *testing.T) {
 		assert.Equal(t, false, casted.Reconnect)
 	})
 
+	t.Run("Remote presence message", func(t *testing.T) {
+		msg := []byte("{\"command\":\"presence\",\"payload\":{\"node\":\"n1\",\"event\":\"join\",\"records\":[{\"stream\":\"chat\",\"id\":\"42\",\"info\":{\"name\":\"Jack\"}}]}}")
+
+		result, err := PubSubMessageFromJSON(msg)
+		assert.Nil(t, err)
+
+		casted := result.(RemotePresenceMessage)
+		assert.Equal(t, "n1", casted.Node)
+		assert.Equal(t, "join", casted.Event)
+		assert.Len(t, casted.Records, 1)
+		assert.Equal(t, "chat", casted.Records[0].Stream)
+		assert.Equal(t, "42", casted.Records[0].ID)
+		assert.Equal(t, map[string]interface{}{"name": "Jack"}, casted.Records[0].Info)
+	})
+
+	t.Run("Remote disconnect message with selectors", func(t *testing.T) {
+		msg := []byte(`{"command":"disconnect","payload":{"sid":"s1","stream":"chat","attributes":{"user_id":"42"},"ack_id":"a1"}}`)
+
+		result, err := PubSubMessageFromJSON(msg)
+		assert.Nil(t, err)
+
+		casted := result.(RemoteDisconnectMessage)
+		assert.Equal(t, "s1", casted.SessionID)
+		assert.Equal(t, "chat", casted.Stream)
+		assert.Equal(t, map[string]string{"user_id": "42"}, casted.Attributes)
+		assert.Equal(t, "a1", casted.AckID)
+	})
+
+	t.Run("Remote stream start message", func(t *testing.T) {
+		msg := []byte("{\"command\":\"stream_start\",\"payload\":{\"identifier\":\"14\",\"channel\":\"chat\",\"stream\":\"chat_1\"}}")
+
+		result, err := PubSubMessageFromJSON(msg)
+		assert.Nil(t, err)
+
+		casted := result.(RemoteStreamStartMessage)
+		assert.Equal(t, "14", casted.Identifier)
+		assert.Equal(t, "chat", casted.Channel)
+		assert.Equal(t, "chat_1", casted.Stream)
+	})
+
+	t.Run("Remote stream stop message", func(t *testing.T) {
+		msg := []byte("{\"command\":\"stream_stop\",\"payload\":{\"identifier\":\"14\",\"channel\":\"chat\"}}")
+
+		result, err := PubSubMessageFromJSON(msg)
+		assert.Nil(t, err)
+
+		casted := result.(RemoteStreamStopMessage)
+		assert.Equal(t, "14", casted.Identifier)
+		assert.Equal(t, "chat", casted.Channel)
+		assert.Empty(t, casted.Stream)
+	})
+
 	t.Run("Broadcast message", func(t *testing.T) {
 		msg := []byte("{\"stream\":\"bread-test\",\"data\":\"test\"}")
 
@@ -78,6 +130,16 @@ func TestPubSubMessageFromJSON(t *testing.T) {
 		assert.Equal(t, "bread-test", casted.Stream)
 		assert.Equal(t, "test", casted.Data)
 	})
+
+	t.Run("Broadcast message with excluded socket", func(t *testing.T) {
+		msg := []byte("{\"stream\":\"bread-test\",\"data\":\"test\",\"exclude_socket\":\"s42\"}")
+
+		result, err := PubSubMessageFromJSON(msg)
+		assert.Nil(t, err)
+
+		casted := result.(StreamMessage)
+		assert.Equal(t, "s42", casted.ExcludeSocket)
+	})
 }
 
 func TestConfirmationMessage(t *testing.T) {
@@ -87,3 +149,27 @@ func TestConfirmationMessage(t *testing.T) {
 func TestRejectionMessage(t *testing.T) {
 	assert.Equal(t, "{\"type\":\"reject_subscription\",\"identifier\":\"test_channel\"}", RejectionMessage("test_channel"))
 }
+
+func TestStreamMessageToReplyFor(t *testing.T) {
+	t.Run("Without position", func(t *testing.T) {
+		msg := StreamMessage{Stream: "test", Data: "{\"text\":\"hello\"}"}
+
+		reply := msg.ToReplyFor("test_channel")
+
+		assert.Equal(t, "test_channel", reply.Identifier)
+		assert.Equal(t, map[string]interface{}{"text": "hello"}, reply.Message)
+		assert.Empty(t, reply.StreamID)
+		assert.Empty(t, reply.Epoch)
+	})
+
+	t.Run("With position", func(t *testing.T) {
+		msg := StreamMessage{Stream: "test", Data: "\"hello\"", Offset: 42, Epoch: "bc"}
+
+		reply := msg.ToReplyFor("test_channel")
+
+		assert.Equal(t, "hello", reply.Message)
+		assert.Equal(t, "test", reply.StreamID)
+		assert.Equal(t, uint64(42), reply.Offset)
+		assert.Equal(t, "bc", reply.Epoch)
+	})
+}
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"github.com/anycable/anycable-go/admin"
+	"github.com/anycable/anycable-go/enats"
 	"github.com/anycable/anycable-go/identity"
 	"github.com/anycable/anycable-go/metrics"
 	"github.com/anycable/anycable-go/node"
@@ -18,12 +20,16 @@ type Config struct {
 	Redis                pubsub.RedisConfig
 	HTTPPubSub           pubsub.HTTPConfig
 	NATSPubSub           pubsub.NATSConfig
+	EmbedNATS            bool
+	EmbeddedNATS         enats.Config
 	Host                 string
 	Port                 int
 	MaxConn              int
 	BroadcastAdapter     string
+	BroadcastDedupSize   int
 	Path                 []string
 	HealthPath           string
+	ReadinessPath        string
 	Headers              []string
 	Cookies              []string
 	SSL                  server.SSLConfig
@@ -37,6 +43,7 @@ type Config struct {
 	Metrics              metrics.Config
 	JWT                  identity.JWTConfig
 	Rails                rails.Config
+	Admin                admin.Config
 }
 
 // NewConfig returns a new empty config
@@ -58,6 +65,8 @@ func NewConfig() Config {
 		Redis:            pubsub.NewRedisConfig(),
 		HTTPPubSub:       pubsub.NewHTTPConfig(),
 		NATSPubSub:       pubsub.NewNATSConfig(),
+		EmbeddedNATS:     enats.NewConfig(),
+		Admin:            admin.NewConfig(),
 		DisconnectQueue:  node.NewDisconnectQueueConfig(),
 		JWT:              identity.NewJWTConfig(""),
 		Rails:            rails.NewConfig(),
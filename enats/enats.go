@@ -0,0 +1,229 @@
+package enats
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/nats-io/nats-server/v2/server"
+)
+
+const (
+	defaultServiceAddr = "nats://127.0.0.1:4222"
+	defaultClusterName = "anycable-cluster"
+
+	serverStartTimeout = 10 * time.Second
+)
+
+// Config contains embedded NATS server configuration
+type Config struct {
+	// Address to accept client connections on
+	ServiceAddr string
+	// Address to accept cluster routes connections on (clustering is disabled if empty)
+	ClusterAddr string
+	// Cluster name (must be the same for all the cluster members)
+	ClusterName string
+	// Routes addresses of other cluster members
+	Routes []string
+	// Server name (must be unique within the cluster; required for JetStream clusters, the hostname is used if empty)
+	ServerName string
+	// Enable JetStream (required by the jetstream broadcast adapter)
+	JetStream bool
+	// JetStream storage directory (a temporary directory is used if empty)
+	StoreDir string
+	// Enable NATS server debug and trace logging
+	Debug bool
+	Trace bool
+}
+
+// NewConfig builds a new config for the embedded NATS server
+func NewConfig() Config {
+	return Config{
+		ServiceAddr: defaultServiceAddr,
+		ClusterName: defaultClusterName,
+	}
+}
+
+// Service manages the embedded NATS server
+type Service struct {
+	config *Config
+	server *server.Server
+	log    *log.Entry
+}
+
+// NewService builds a new embedded NATS service
+func NewService(c *Config) *Service {
+	return &Service{
+		config: c,
+		log:    log.WithFields(log.Fields{"context": "enats"}),
+	}
+}
+
+// Start starts the NATS server and waits for it to be ready to accept connections
+func (s *Service) Start() error {
+	opts, err := s.serverOptions()
+
+	if err != nil {
+		return err
+	}
+
+	srv, err := server.NewServer(opts)
+
+	if err != nil {
+		return fmt.Errorf("Failed to configure embedded NATS server: %v", err)
+	}
+
+	l := &logger{log: s.log, fatal: make(chan error, 1)}
+	srv.SetLoggerV2(l, s.config.Debug, s.config.Trace, false)
+
+	go srv.Start()
+
+	ready := make(chan bool, 1)
+
+	go func() {
+		ready <- srv.ReadyForConnections(serverStartTimeout)
+	}()
+
+	select {
+	case ok := <-ready:
+		if !ok {
+			srv.Shutdown()
+			return fmt.Errorf("Embedded NATS server hasn't started in %s", serverStartTimeout)
+		}
+	case err := <-l.fatal:
+		srv.Shutdown()
+		return fmt.Errorf("Failed to start embedded NATS server: %v", err)
+	}
+
+	s.server = srv
+
+	if opts.Cluster.Port != 0 {
+		s.log.Infof("Embedded NATS server started at %s (cluster: %s, routes: %s)", s.config.ServiceAddr, s.config.ClusterAddr, strings.Join(s.config.Routes, ", "))
+	} else {
+		s.log.Infof("Embedded NATS server started at %s", s.config.ServiceAddr)
+	}
+
+	return nil
+}
+
+// ClientURL returns the URL to connect to the server
+func (s *Service) ClientURL() string {
+	return s.server.ClientURL()
+}
+
+// Shutdown stops the NATS server
+func (s *Service) Shutdown() error {
+	if s.server == nil {
+		return nil
+	}
+
+	s.server.Shutdown()
+	s.server.WaitForShutdown()
+
+	return nil
+}
+
+func (s *Service) serverOptions() (*server.Options, error) {
+	host, port, err := parseAddress(s.config.ServiceAddr)
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid embedded NATS service address: %v", err)
+	}
+
+	opts := &server.Options{
+		Host:       host,
+		Port:       port,
+		ServerName: s.config.ServerName,
+		JetStream:  s.config.JetStream,
+		StoreDir:   s.config.StoreDir,
+		// Signals are handled by the application
+		NoSigs: true,
+	}
+
+	if s.config.ClusterAddr == "" {
+		return opts, nil
+	}
+
+	if opts.JetStream && opts.ServerName == "" {
+		if opts.ServerName, err = os.Hostname(); err != nil {
+			return nil, fmt.Errorf("Failed to obtain embedded NATS server name: %v", err)
+		}
+	}
+
+	clusterHost, clusterPort, err := parseAddress(s.config.ClusterAddr)
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid embedded NATS cluster address: %v", err)
+	}
+
+	opts.Cluster = server.ClusterOpts{
+		Name: s.config.ClusterName,
+		Host: clusterHost,
+		Port: clusterPort,
+	}
+
+	if len(s.config.Routes) > 0 {
+		opts.Routes = server.RoutesFromStr(strings.Join(s.config.Routes, ","))
+	}
+
+	return opts, nil
+}
+
+// parseAddress extracts host and port from the address (e.g., "nats://0.0.0.0:4222")
+func parseAddress(addr string) (string, int, error) {
+	uri, err := url.Parse(addr)
+
+	if err != nil {
+		return "", 0, err
+	}
+
+	port, err := strconv.Atoi(uri.Port())
+
+	if err != nil {
+		return "", 0, fmt.Errorf("port is missing: %s", addr)
+	}
+
+	return uri.Hostname(), port, nil
+}
+
+// logger passes NATS server logs to the application logger
+// (fatal errors are reported via the channel instead of exiting the process)
+type logger struct {
+	log   *log.Entry
+	fatal chan error
+}
+
+var _ server.Logger = (*logger)(nil)
+
+func (l *logger) Noticef(format string, v ...interface{}) {
+	l.log.Infof(format, v...)
+}
+
+func (l *logger) Warnf(format string, v ...interface{}) {
+	l.log.Warnf(format, v...)
+}
+
+func (l *logger) Fatalf(format string, v ...interface{}) {
+	l.log.Errorf(format, v...)
+
+	select {
+	case l.fatal <- fmt.Errorf(format, v...):
+	default:
+	}
+}
+
+func (l *logger) Errorf(format string, v ...interface{}) {
+	l.log.Errorf(format, v...)
+}
+
+func (l *logger) Debugf(format string, v ...interface{}) {
+	l.log.Debugf(format, v...)
+}
+
+func (l *logger) Tracef(format string, v ...interface{}) {
+	l.log.Debugf(format, v...)
+}
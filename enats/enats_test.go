@@ -0,0 +1,155 @@
+package enats
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService(t *testing.T) {
+	config := NewConfig()
+	config.ServiceAddr = fmt.Sprintf("nats://127.0.0.1:%d", freePort(t))
+
+	service := NewService(&config)
+	require.NoError(t, service.Start())
+
+	nc, err := nats.Connect(service.ClientURL())
+	require.NoError(t, err)
+
+	nc.Close()
+
+	require.NoError(t, service.Shutdown())
+
+	_, err = nats.Connect(config.ServiceAddr)
+	assert.Error(t, err)
+}
+
+func TestServiceCluster(t *testing.T) {
+	clusterA := fmt.Sprintf("nats://127.0.0.1:%d", freePort(t))
+	clusterB := fmt.Sprintf("nats://127.0.0.1:%d", freePort(t))
+
+	configA := NewConfig()
+	configA.ServiceAddr = fmt.Sprintf("nats://127.0.0.1:%d", freePort(t))
+	configA.ClusterAddr = clusterA
+	configA.Routes = []string{clusterB}
+
+	configB := NewConfig()
+	configB.ServiceAddr = fmt.Sprintf("nats://127.0.0.1:%d", freePort(t))
+	configB.ClusterAddr = clusterB
+	configB.Routes = []string{clusterA}
+
+	serviceA := NewService(&configA)
+	require.NoError(t, serviceA.Start())
+	defer serviceA.Shutdown() // nolint:errcheck
+
+	serviceB := NewService(&configB)
+	require.NoError(t, serviceB.Start())
+	defer serviceB.Shutdown() // nolint:errcheck
+
+	require.Eventually(t, func() bool {
+		return serviceA.server.NumRoutes() > 0 && serviceB.server.NumRoutes() > 0
+	}, 5*time.Second, 50*time.Millisecond)
+
+	ncA, err := nats.Connect(serviceA.ClientURL())
+	require.NoError(t, err)
+	defer ncA.Close()
+
+	ncB, err := nats.Connect(serviceB.ClientURL())
+	require.NoError(t, err)
+	defer ncB.Close()
+
+	sub, err := ncB.SubscribeSync("__anycable__")
+	require.NoError(t, err)
+	require.NoError(t, ncB.Flush())
+
+	// Subscription interest is propagated via routes asynchronously
+	require.Eventually(t, func() bool {
+		require.NoError(t, ncA.Publish("__anycable__", []byte("hello")))
+
+		msg, err := sub.NextMsg(100 * time.Millisecond)
+
+		return err == nil && string(msg.Data) == "hello"
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestServiceInvalidAddress(t *testing.T) {
+	config := NewConfig()
+	config.ServiceAddr = "nats://127.0.0.1"
+
+	service := NewService(&config)
+	assert.Error(t, service.Start())
+}
+
+func TestServiceAddressInUse(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	config := NewConfig()
+	config.ServiceAddr = fmt.Sprintf("nats://127.0.0.1:%d", l.Addr().(*net.TCPAddr).Port)
+
+	service := NewService(&config)
+
+	start := time.Now()
+
+	// The error must be returned instead of exiting the process
+	assert.Error(t, service.Start())
+	assert.Less(t, time.Since(start), serverStartTimeout)
+}
+
+func TestServiceJetStream(t *testing.T) {
+	config := NewConfig()
+	config.ServiceAddr = fmt.Sprintf("nats://127.0.0.1:%d", freePort(t))
+	config.JetStream = true
+	config.StoreDir = t.TempDir()
+
+	service := NewService(&config)
+	require.NoError(t, service.Start())
+	defer service.Shutdown() // nolint:errcheck
+
+	nc, err := nats.Connect(service.ClientURL())
+	require.NoError(t, err)
+	defer nc.Close()
+
+	js, err := nc.JetStream()
+	require.NoError(t, err)
+
+	_, err = js.AddStream(&nats.StreamConfig{Name: "test", Subjects: []string{"test"}})
+	require.NoError(t, err)
+
+	_, err = js.Publish("test", []byte("hello"))
+	require.NoError(t, err)
+
+	t.Run("Server name for JetStream cluster", func(t *testing.T) {
+		config := NewConfig()
+		config.ClusterAddr = "nats://127.0.0.1:6222"
+		config.JetStream = true
+
+		opts, err := NewService(&config).serverOptions()
+		require.NoError(t, err)
+
+		hostname, _ := os.Hostname()
+		assert.Equal(t, hostname, opts.ServerName)
+
+		config.ServerName = "anycable-1"
+
+		opts, err = NewService(&config).serverOptions()
+		require.NoError(t, err)
+
+		assert.Equal(t, "anycable-1", opts.ServerName)
+	})
+}
+
+func freePort(t *testing.T) int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	return l.Addr().(*net.TCPAddr).Port
+}
@@ -21,3 +21,5 @@ type Encoder interface {
 }
 
 var _ Encoder = (*JSON)(nil)
+var _ Encoder = (*Msgpack)(nil)
+var _ Encoder = (*Protobuf)(nil)
@@ -53,6 +53,11 @@ func (msg *CachedEncodedMessage) GetType() string {
 	return msg.target.GetType()
 }
 
+// Target returns the original message
+func (msg *CachedEncodedMessage) Target() EncodedMessage {
+	return msg.target
+}
+
 func (msg *CachedEncodedMessage) Fetch(id string, callback EncodingFunction) (*ws.SentFrame, error) {
 	return msg.cache.Fetch(msg.target, id, callback)
 }
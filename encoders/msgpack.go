@@ -0,0 +1,72 @@
+package encoders
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/anycable/anycable-go/common"
+	"github.com/anycable/anycable-go/ws"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+const msgpackEncoderID = "msgpack"
+
+type Msgpack struct {
+}
+
+func (Msgpack) ID() string {
+	return msgpackEncoderID
+}
+
+func (Msgpack) Encode(msg EncodedMessage) (*ws.SentFrame, error) {
+	b, err := msgpackMarshal(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ws.SentFrame{FrameType: ws.BinaryFrame, Payload: b}, nil
+}
+
+// EncodeTransmission converts a JSON-encoded transmission (from RPC) into Msgpack
+func (Msgpack) EncodeTransmission(msg string) (*ws.SentFrame, error) {
+	var data interface{}
+
+	if err := json.Unmarshal([]byte(msg), &data); err != nil {
+		return nil, err
+	}
+
+	b, err := msgpackMarshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ws.SentFrame{FrameType: ws.BinaryFrame, Payload: b}, nil
+}
+
+func (Msgpack) Decode(raw []byte) (*common.Message, error) {
+	msg := &common.Message{}
+
+	dec := msgpack.NewDecoder(bytes.NewReader(raw))
+	dec.SetCustomStructTag("json")
+
+	if err := dec.Decode(msg); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+// msgpackMarshal encodes a value using JSON struct tags,
+// so we don't have to duplicate them for every message type
+func msgpackMarshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,62 @@
+package encoders
+
+import (
+	"testing"
+
+	"github.com/anycable/anycable-go/common"
+	"github.com/anycable/anycable-go/ws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestMsgpackEncoder(t *testing.T) {
+	coder := Msgpack{}
+
+	t.Run(".Encode", func(t *testing.T) {
+		msg := &common.Reply{Type: "test", Identifier: "test_channel", Message: "hello"}
+
+		actual, err := coder.Encode(msg)
+		require.NoError(t, err)
+
+		assert.Equal(t, ws.BinaryFrame, actual.FrameType)
+
+		var decoded map[string]interface{}
+		require.NoError(t, msgpack.Unmarshal(actual.Payload, &decoded))
+
+		assert.Equal(t, map[string]interface{}{"type": "test", "identifier": "test_channel", "message": "hello"}, decoded)
+	})
+
+	t.Run(".EncodeTransmission", func(t *testing.T) {
+		msg := "{\"type\":\"test\",\"identifier\":\"test_channel\",\"message\":{\"text\":\"hello\"}}"
+
+		actual, err := coder.EncodeTransmission(msg)
+		require.NoError(t, err)
+
+		assert.Equal(t, ws.BinaryFrame, actual.FrameType)
+
+		var decoded map[string]interface{}
+		require.NoError(t, msgpack.Unmarshal(actual.Payload, &decoded))
+
+		assert.Equal(t, "test", decoded["type"])
+		assert.Equal(t, "test_channel", decoded["identifier"])
+		assert.Equal(t, map[string]interface{}{"text": "hello"}, decoded["message"])
+	})
+
+	t.Run(".EncodeTransmission with invalid JSON", func(t *testing.T) {
+		_, err := coder.EncodeTransmission("{\"type\":")
+		assert.Error(t, err)
+	})
+
+	t.Run(".Decode", func(t *testing.T) {
+		msg, err := msgpack.Marshal(map[string]interface{}{"command": "test", "identifier": "test_channel", "data": "hello"})
+		require.NoError(t, err)
+
+		actual, err := coder.Decode(msg)
+
+		assert.NoError(t, err)
+		assert.Equal(t, actual.Command, "test")
+		assert.Equal(t, actual.Identifier, "test_channel")
+		assert.Equal(t, actual.Data, "hello")
+	})
+}
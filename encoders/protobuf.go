@@ -0,0 +1,170 @@
+package encoders
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/anycable/anycable-go/common"
+	"github.com/anycable/anycable-go/protos/ac"
+	"github.com/anycable/anycable-go/ws"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+)
+
+const protobufEncoderID = "protobuf"
+
+type Protobuf struct {
+}
+
+func (Protobuf) ID() string {
+	return protobufEncoderID
+}
+
+func (Protobuf) Encode(msg EncodedMessage) (*ws.SentFrame, error) {
+	buf := &ac.Message{}
+
+	switch v := msg.(type) {
+	case *common.Reply:
+		if err := fillProtobufReply(buf, v); err != nil {
+			return nil, err
+		}
+	case *common.PingMessage:
+		buf.Type = ac.Type_ping
+
+		if v.Message != nil {
+			b, err := msgpackMarshal(v.Message)
+			if err != nil {
+				return nil, err
+			}
+			buf.Message = b
+		}
+	case *common.DisconnectMessage:
+		buf.Type = ac.Type_disconnect
+		buf.Reason = v.Reason
+		buf.Reconnect = v.Reconnect
+		buf.ReconnectDelay = int32(v.ReconnectDelay)
+	default:
+		return nil, fmt.Errorf("Unsupported message type: %T", msg)
+	}
+
+	return protobufFrame(buf)
+}
+
+// protobufTransmission contains all the fields of Protobuf messages sent to clients
+type protobufTransmission struct {
+	common.Reply
+	ReconnectDelay int `json:"reconnect_delay,omitempty"`
+}
+
+// EncodeTransmission converts a JSON-encoded transmission (from RPC) into a Protobuf message.
+// Transmissions with fields missing in the schema are rejected (instead of losing them silently).
+func (Protobuf) EncodeTransmission(msg string) (*ws.SentFrame, error) {
+	transmission := protobufTransmission{}
+
+	dec := json.NewDecoder(strings.NewReader(msg))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(&transmission); err != nil {
+		return nil, fmt.Errorf("Unsupported transmission for Protobuf encoding: %v", err)
+	}
+
+	buf := &ac.Message{ReconnectDelay: int32(transmission.ReconnectDelay)}
+
+	if err := fillProtobufReply(buf, &transmission.Reply); err != nil {
+		return nil, err
+	}
+
+	return protobufFrame(buf)
+}
+
+func fillProtobufReply(buf *ac.Message, v *common.Reply) error {
+	buf.Type = protobufType(v.Type)
+	buf.Identifier = v.Identifier
+	buf.Reason = v.Reason
+	buf.Reconnect = v.Reconnect
+	buf.StreamId = v.StreamID
+	buf.Epoch = v.Epoch
+	buf.Offset = v.Offset
+	buf.RestoreToken = v.RestoreToken
+
+	if v.Message != nil {
+		b, err := msgpackMarshal(v.Message)
+		if err != nil {
+			return err
+		}
+		buf.Message = b
+	}
+
+	return nil
+}
+
+func protobufFrame(buf *ac.Message) (*ws.SentFrame, error) {
+	b, err := proto.Marshal(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ws.SentFrame{FrameType: ws.BinaryFrame, Payload: b}, nil
+}
+
+func (Protobuf) Decode(raw []byte) (*common.Message, error) {
+	buf := &ac.Message{}
+
+	if err := proto.Unmarshal(raw, buf); err != nil {
+		return nil, err
+	}
+
+	msg := &common.Message{
+		Command:    protobufCommand(buf.Command),
+		Identifier: buf.Identifier,
+	}
+
+	if buf.Data != "" {
+		msg.Data = buf.Data
+	}
+
+	if buf.History != nil {
+		msg.History = &common.HistoryRequest{Since: buf.History.Since}
+
+		if buf.History.Streams != nil {
+			msg.History.Streams = make(map[string]common.HistoryPosition, len(buf.History.Streams))
+
+			for name, pos := range buf.History.Streams {
+				msg.History.Streams[name] = common.HistoryPosition{Epoch: pos.Epoch, Offset: pos.Offset}
+			}
+		}
+	}
+
+	if buf.Presence != nil {
+		msg.Presence = &common.PresenceRecord{ID: buf.Presence.Id}
+
+		if len(buf.Presence.Info) > 0 {
+			var info interface{}
+
+			if err := msgpack.Unmarshal(buf.Presence.Info, &info); err != nil {
+				return nil, err
+			}
+
+			msg.Presence.Info = info
+		}
+	}
+
+	return msg, nil
+}
+
+func protobufCommand(c ac.Command) string {
+	if c == ac.Command_presence_query {
+		return "presence"
+	}
+
+	return c.String()
+}
+
+func protobufType(t string) ac.Type {
+	if v, ok := ac.Type_value[t]; ok {
+		return ac.Type(v)
+	}
+
+	return ac.Type_no_type
+}
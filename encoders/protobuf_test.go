@@ -0,0 +1,185 @@
+package encoders
+
+import (
+	"testing"
+
+	"github.com/anycable/anycable-go/common"
+	"github.com/anycable/anycable-go/protos/ac"
+	"github.com/anycable/anycable-go/ws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestProtobufEncoder(t *testing.T) {
+	coder := Protobuf{}
+
+	t.Run(".Encode", func(t *testing.T) {
+		msg := &common.Reply{Type: "confirm_subscription", Identifier: "test_channel", Message: map[string]string{"text": "hello"}}
+
+		actual, err := coder.Encode(msg)
+		require.NoError(t, err)
+
+		assert.Equal(t, ws.BinaryFrame, actual.FrameType)
+
+		decoded := &ac.Message{}
+		require.NoError(t, proto.Unmarshal(actual.Payload, decoded))
+
+		assert.Equal(t, ac.Type_confirm_subscription, decoded.Type)
+		assert.Equal(t, "test_channel", decoded.Identifier)
+
+		var data map[string]interface{}
+		require.NoError(t, msgpack.Unmarshal(decoded.Message, &data))
+
+		assert.Equal(t, map[string]interface{}{"text": "hello"}, data)
+	})
+
+	t.Run(".Encode ping", func(t *testing.T) {
+		actual, err := coder.Encode(&common.PingMessage{Type: "ping", Message: 42})
+		require.NoError(t, err)
+
+		decoded := &ac.Message{}
+		require.NoError(t, proto.Unmarshal(actual.Payload, decoded))
+
+		assert.Equal(t, ac.Type_ping, decoded.Type)
+
+		var ts int
+		require.NoError(t, msgpack.Unmarshal(decoded.Message, &ts))
+
+		assert.Equal(t, 42, ts)
+	})
+
+	t.Run(".Encode disconnect", func(t *testing.T) {
+		actual, err := coder.Encode(common.NewDisconnectMessage("unauthorized", true))
+		require.NoError(t, err)
+
+		decoded := &ac.Message{}
+		require.NoError(t, proto.Unmarshal(actual.Payload, decoded))
+
+		assert.Equal(t, ac.Type_disconnect, decoded.Type)
+		assert.Equal(t, "unauthorized", decoded.Reason)
+		assert.True(t, decoded.Reconnect)
+	})
+
+	t.Run(".Encode disconnect with reconnect delay", func(t *testing.T) {
+		msg := common.NewDisconnectMessage("server_restart", true)
+		msg.ReconnectDelay = 1500
+
+		actual, err := coder.Encode(msg)
+		require.NoError(t, err)
+
+		decoded := &ac.Message{}
+		require.NoError(t, proto.Unmarshal(actual.Payload, decoded))
+
+		assert.Equal(t, int32(1500), decoded.ReconnectDelay)
+	})
+
+	t.Run(".EncodeTransmission", func(t *testing.T) {
+		msg := "{\"type\":\"welcome\",\"identifier\":\"test_channel\",\"message\":\"hello\"}"
+
+		actual, err := coder.EncodeTransmission(msg)
+		require.NoError(t, err)
+
+		decoded := &ac.Message{}
+		require.NoError(t, proto.Unmarshal(actual.Payload, decoded))
+
+		assert.Equal(t, ac.Type_welcome, decoded.Type)
+		assert.Equal(t, "test_channel", decoded.Identifier)
+
+		var data string
+		require.NoError(t, msgpack.Unmarshal(decoded.Message, &data))
+
+		assert.Equal(t, "hello", data)
+	})
+
+	t.Run(".EncodeTransmission with stream position and restore token", func(t *testing.T) {
+		msg := `{"type":"disconnect","identifier":"test_channel","message":{"text":"hi"},"reason":"server_restart","reconnect":true,` +
+			`"stream_id":"chat_1","epoch":"bc","offset":42,"restore_token":"secret","reconnect_delay":1500}`
+
+		actual, err := coder.EncodeTransmission(msg)
+		require.NoError(t, err)
+
+		decoded := &ac.Message{}
+		require.NoError(t, proto.Unmarshal(actual.Payload, decoded))
+
+		assert.Equal(t, ac.Type_disconnect, decoded.Type)
+		assert.Equal(t, "test_channel", decoded.Identifier)
+		assert.Equal(t, "server_restart", decoded.Reason)
+		assert.True(t, decoded.Reconnect)
+		assert.Equal(t, "chat_1", decoded.StreamId)
+		assert.Equal(t, "bc", decoded.Epoch)
+		assert.Equal(t, uint64(42), decoded.Offset)
+		assert.Equal(t, "secret", decoded.RestoreToken)
+		assert.Equal(t, int32(1500), decoded.ReconnectDelay)
+
+		var data map[string]interface{}
+		require.NoError(t, msgpack.Unmarshal(decoded.Message, &data))
+
+		assert.Equal(t, map[string]interface{}{"text": "hi"}, data)
+	})
+
+	t.Run(".EncodeTransmission with unknown fields", func(t *testing.T) {
+		_, err := coder.EncodeTransmission(`{"type":"welcome","sid":"123"}`)
+		assert.Error(t, err)
+	})
+
+	t.Run(".Decode", func(t *testing.T) {
+		msg, err := proto.Marshal(&ac.Message{Command: ac.Command_message, Identifier: "test_channel", Data: "hello"})
+		require.NoError(t, err)
+
+		actual, err := coder.Decode(msg)
+
+		assert.NoError(t, err)
+		assert.Equal(t, actual.Command, "message")
+		assert.Equal(t, actual.Identifier, "test_channel")
+		assert.Equal(t, actual.Data, "hello")
+	})
+
+	t.Run(".Decode history request", func(t *testing.T) {
+		msg, err := proto.Marshal(&ac.Message{
+			Command:    ac.Command_history,
+			Identifier: "test_channel",
+			History: &ac.HistoryRequest{
+				Since:   1660000000,
+				Streams: map[string]*ac.StreamHistoryRequest{"chat": {Epoch: "bc", Offset: 42}},
+			},
+		})
+		require.NoError(t, err)
+
+		actual, err := coder.Decode(msg)
+		require.NoError(t, err)
+
+		assert.Equal(t, "history", actual.Command)
+		assert.Equal(t, int64(1660000000), actual.History.Since)
+		assert.Equal(t, common.HistoryPosition{Epoch: "bc", Offset: 42}, actual.History.Streams["chat"])
+	})
+	t.Run(".Decode presence join", func(t *testing.T) {
+		info, err := msgpackMarshal(map[string]string{"name": "Jack"})
+		require.NoError(t, err)
+
+		msg, err := proto.Marshal(&ac.Message{
+			Command:    ac.Command_join,
+			Identifier: "test_channel",
+			Presence:   &ac.PresenceRequest{Id: "42", Info: info},
+		})
+		require.NoError(t, err)
+
+		actual, err := coder.Decode(msg)
+		require.NoError(t, err)
+
+		assert.Equal(t, "join", actual.Command)
+		assert.Equal(t, "42", actual.Presence.ID)
+		assert.Equal(t, map[string]interface{}{"name": "Jack"}, actual.Presence.Info)
+	})
+
+	t.Run(".Decode presence query", func(t *testing.T) {
+		msg, err := proto.Marshal(&ac.Message{Command: ac.Command_presence_query, Identifier: "test_channel"})
+		require.NoError(t, err)
+
+		actual, err := coder.Decode(msg)
+		require.NoError(t, err)
+
+		assert.Equal(t, "presence", actual.Command)
+	})
+}
@@ -0,0 +1,57 @@
+package hub
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// identifiersAttributes returns top-level scalar attributes of the JSON-encoded identifiers
+// in the "name=value" format (e.g., `{"user_id":42}` -> ["user_id=42"]).
+// Non-JSON identifiers have no attributes.
+func identifiersAttributes(identifiers string) []string {
+	if !strings.HasPrefix(identifiers, "{") {
+		return nil
+	}
+
+	var fields map[string]json.RawMessage
+
+	if err := json.Unmarshal([]byte(identifiers), &fields); err != nil {
+		return nil
+	}
+
+	attributes := make([]string, 0, len(fields))
+
+	for name, raw := range fields {
+		if value, ok := attributeValue(raw); ok {
+			attributes = append(attributes, attributeKey(name, value))
+		}
+	}
+
+	return attributes
+}
+
+func attributeKey(name string, value string) string {
+	return name + "=" + value
+}
+
+// attributeValue returns strings as is and other scalars (numbers, booleans) as their JSON representation
+func attributeValue(raw json.RawMessage) (string, bool) {
+	if len(raw) == 0 {
+		return "", false
+	}
+
+	switch raw[0] {
+	case '"':
+		var val string
+
+		if err := json.Unmarshal(raw, &val); err != nil {
+			return "", false
+		}
+
+		return val, true
+	case '{', '[', 'n':
+		return "", false
+	default:
+		return string(raw), true
+	}
+}
@@ -0,0 +1,17 @@
+package hub
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdentifiersAttributes(t *testing.T) {
+	assert.ElementsMatch(t,
+		[]string{"user_id=42", "name=jack", "admin=true"},
+		identifiersAttributes(`{"user_id":42,"name":"jack","admin":true,"tags":["a"],"meta":{"a":1},"deleted":null}`),
+	)
+
+	assert.Empty(t, identifiersAttributes("anonymous"))
+	assert.Empty(t, identifiersAttributes("{invalid"))
+}
@@ -0,0 +1,185 @@
+package hub
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/anycable/anycable-go/common"
+	nanoid "github.com/matoous/go-nanoid"
+)
+
+var (
+	// ErrUnknownEpoch is returned when the requested epoch doesn't match the current one
+	// (e.g., the server has been restarted or the stream history has expired)
+	ErrUnknownEpoch = errors.New("Unknown epoch")
+	// ErrOffsetEvicted is returned when the requested offset is no longer in the history
+	ErrOffsetEvicted = errors.New("Requested offset has been evicted from the history")
+	// ErrOffsetOutOfRange is returned when the requested offset is greater than the last one
+	ErrOffsetOutOfRange = errors.New("Requested offset is out of range")
+)
+
+type historyEntry struct {
+	msg       *common.StreamMessage
+	timestamp int64
+}
+
+type streamHistory struct {
+	epoch   string
+	offset  uint64
+	entries []historyEntry
+}
+
+// History keeps a bounded in-memory log of recent messages per stream
+type History struct {
+	// Max number of messages to keep per stream
+	limit int
+	// How long to keep messages
+	ttl time.Duration
+
+	streams map[string]*streamHistory
+	mu      sync.RWMutex
+}
+
+// NewHistory builds a new History struct
+func NewHistory(limit int, ttl time.Duration) *History {
+	return &History{
+		limit:   limit,
+		ttl:     ttl,
+		streams: make(map[string]*streamHistory),
+	}
+}
+
+// Add assigns the offset and epoch to the message and stores it in the history
+func (h *History) Add(msg *common.StreamMessage) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+
+	st, ok := h.streams[msg.Stream]
+
+	if !ok {
+		st = &streamHistory{epoch: newEpoch()}
+		h.streams[msg.Stream] = st
+	}
+
+	st.offset++
+
+	msg.Offset = st.offset
+	msg.Epoch = st.epoch
+
+	st.entries = append(st.entries, historyEntry{msg: msg, timestamp: now.Unix()})
+
+	h.evict(st, now)
+}
+
+// From returns messages with offsets greater than the specified one
+func (h *History) From(stream string, epoch string, offset uint64) ([]*common.StreamMessage, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	st, ok := h.streams[stream]
+
+	if !ok || st.epoch != epoch {
+		return nil, ErrUnknownEpoch
+	}
+
+	if offset > st.offset {
+		return nil, ErrOffsetOutOfRange
+	}
+
+	if offset == st.offset {
+		return []*common.StreamMessage{}, nil
+	}
+
+	if len(st.entries) == 0 || st.entries[0].msg.Offset > offset+1 {
+		return nil, ErrOffsetEvicted
+	}
+
+	start := offset + 1 - st.entries[0].msg.Offset
+
+	return entriesToMessages(st.entries[start:]), nil
+}
+
+// Since returns messages added at or after the specified time (Unix timestamp in seconds)
+func (h *History) Since(stream string, since int64) []*common.StreamMessage {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	st, ok := h.streams[stream]
+
+	if !ok {
+		return []*common.StreamMessage{}
+	}
+
+	for i, entry := range st.entries {
+		if entry.timestamp >= since {
+			return entriesToMessages(st.entries[i:])
+		}
+	}
+
+	return []*common.StreamMessage{}
+}
+
+// Size returns the number of streams with history
+func (h *History) Size() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return len(h.streams)
+}
+
+// Expire removes stale messages and drops streams with no messages left
+func (h *History) Expire() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+
+	for name, st := range h.streams {
+		h.evict(st, now)
+
+		if len(st.entries) == 0 {
+			delete(h.streams, name)
+		}
+	}
+}
+
+func (h *History) evict(st *streamHistory, now time.Time) {
+	deadline := now.Add(-h.ttl).Unix()
+
+	start := 0
+
+	if len(st.entries) > h.limit {
+		start = len(st.entries) - h.limit
+	}
+
+	for start < len(st.entries) && st.entries[start].timestamp < deadline {
+		start++
+	}
+
+	if start > 0 {
+		st.entries = st.entries[start:]
+	}
+}
+
+func entriesToMessages(entries []historyEntry) []*common.StreamMessage {
+	res := make([]*common.StreamMessage, len(entries))
+
+	for i, entry := range entries {
+		res[i] = entry.msg
+	}
+
+	return res
+}
+
+func newEpoch() string {
+	epoch, err := nanoid.Generate("abcdefghijklmnopqrstuvwxyz0123456789", 8)
+
+	if err != nil {
+		return "0"
+	}
+
+	return epoch
+}
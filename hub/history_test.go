@@ -0,0 +1,125 @@
+package hub
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/anycable/anycable-go/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistoryAdd(t *testing.T) {
+	history := NewHistory(10, time.Minute)
+
+	first := &common.StreamMessage{Stream: "test", Data: "1"}
+	second := &common.StreamMessage{Stream: "test", Data: "2"}
+	other := &common.StreamMessage{Stream: "other", Data: "1"}
+
+	history.Add(first)
+	history.Add(second)
+	history.Add(other)
+
+	assert.Equal(t, uint64(1), first.Offset)
+	assert.Equal(t, uint64(2), second.Offset)
+	assert.Equal(t, uint64(1), other.Offset)
+
+	assert.NotEmpty(t, first.Epoch)
+	assert.Equal(t, first.Epoch, second.Epoch)
+
+	assert.Equal(t, 2, history.Size())
+}
+
+func TestHistoryFrom(t *testing.T) {
+	history := NewHistory(3, time.Minute)
+
+	for i := 1; i <= 5; i++ {
+		history.Add(&common.StreamMessage{Stream: "test", Data: fmt.Sprintf("%d", i)})
+	}
+
+	epoch := history.streams["test"].epoch
+
+	t.Run("Returns messages after the offset", func(t *testing.T) {
+		messages, err := history.From("test", epoch, 3)
+		require.NoError(t, err)
+
+		require.Len(t, messages, 2)
+		assert.Equal(t, "4", messages[0].Data)
+		assert.Equal(t, "5", messages[1].Data)
+	})
+
+	t.Run("Returns all retained messages", func(t *testing.T) {
+		messages, err := history.From("test", epoch, 2)
+		require.NoError(t, err)
+
+		assert.Len(t, messages, 3)
+	})
+
+	t.Run("Returns nothing when up to date", func(t *testing.T) {
+		messages, err := history.From("test", epoch, 5)
+		require.NoError(t, err)
+
+		assert.Empty(t, messages)
+	})
+
+	t.Run("When offset has been evicted", func(t *testing.T) {
+		_, err := history.From("test", epoch, 1)
+		assert.Equal(t, ErrOffsetEvicted, err)
+	})
+
+	t.Run("When offset is out of range", func(t *testing.T) {
+		_, err := history.From("test", epoch, 6)
+		assert.Equal(t, ErrOffsetOutOfRange, err)
+	})
+
+	t.Run("When epoch doesn't match", func(t *testing.T) {
+		_, err := history.From("test", "unknown", 4)
+		assert.Equal(t, ErrUnknownEpoch, err)
+	})
+
+	t.Run("When stream is unknown", func(t *testing.T) {
+		_, err := history.From("unknown", epoch, 4)
+		assert.Equal(t, ErrUnknownEpoch, err)
+	})
+}
+
+func TestHistorySince(t *testing.T) {
+	history := NewHistory(10, time.Minute)
+
+	history.Add(&common.StreamMessage{Stream: "test", Data: "1"})
+	history.Add(&common.StreamMessage{Stream: "test", Data: "2"})
+
+	history.streams["test"].entries[0].timestamp -= 30
+
+	messages := history.Since("test", time.Now().Unix()-10)
+	require.Len(t, messages, 1)
+	assert.Equal(t, "2", messages[0].Data)
+
+	assert.Len(t, history.Since("test", time.Now().Unix()-60), 2)
+	assert.Empty(t, history.Since("unknown", 0))
+}
+
+func TestHistoryExpire(t *testing.T) {
+	history := NewHistory(10, time.Minute)
+
+	history.Add(&common.StreamMessage{Stream: "test", Data: "1"})
+	history.Add(&common.StreamMessage{Stream: "test", Data: "2"})
+	history.Add(&common.StreamMessage{Stream: "stale", Data: "1"})
+
+	history.streams["test"].entries[0].timestamp -= 120
+	history.streams["stale"].entries[0].timestamp -= 120
+
+	history.Expire()
+
+	assert.Equal(t, 1, history.Size())
+
+	epoch := history.streams["test"].epoch
+
+	_, err := history.From("test", epoch, 0)
+	assert.Equal(t, ErrOffsetEvicted, err)
+
+	messages, err := history.From("test", epoch, 1)
+	require.NoError(t, err)
+	assert.Len(t, messages, 1)
+}
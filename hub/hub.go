@@ -1,7 +1,10 @@
 package hub
 
 import (
+	"errors"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/anycable/anycable-go/common"
 	"github.com/anycable/anycable-go/encoders"
@@ -30,33 +33,14 @@ type HubRegistration struct {
 	session HubSession
 }
 
-// Hub stores all the sessions and the corresponding subscriptions info
+// Hub stores all the sessions and the corresponding subscriptions info.
+// The state is split into shards (by stream name for subscriptions and by session ID for registrations),
+// so operations with different streams and sessions don't contend for the same locks.
 type Hub struct {
-	// Registered sessions
-	sessions map[string]HubSession
+	shards []*hubShard
 
-	// Identifiers to session
-	identifiers map[string]map[string]bool
-
-	// Maps streams to sessions with identifiers
-	// stream -> sid -> identifier -> true
-	streams map[string]map[string]map[string]bool
-
-	// Maps sessions to identifiers to streams
-	// sid -> identifier -> [stream]
-	sessionsStreams map[string]map[string][]string
-
-	// Messages for specified stream
-	broadcast chan *common.StreamMessage
-
-	// Remote disconnect commands
-	disconnect chan *common.RemoteDisconnectMessage
-
-	// Register requests from the sessions
-	register chan HubRegistration
-
-	// Subscribe requests to streams
-	subscribe chan HubSubscription
+	// Subscriptions to stream patterns (e.g., "orders:*")
+	patterns *streamPatterns
 
 	// Control channel to shutdown hub
 	shutdown chan struct{}
@@ -64,49 +48,137 @@ type Hub struct {
 	// Synchronization group to wait for gracefully disconnect of all sessions
 	done sync.WaitGroup
 
+	// Protects from starting shards after shutdown
+	shutdownMu sync.Mutex
+	closed     bool
+
 	// Log context
 	log *log.Entry
 
 	// go pool
 	pool *utils.GoPool
 
-	// mutex for streams mappings
-	streamsMu sync.RWMutex
+	// Executes broadcasts to the same stream in order (on top of the go pool)
+	streamsScheduler *utils.KeyedScheduler
 
-	// mutex for sessions tracking
-	sessionsMu sync.RWMutex
+	// Streams history (nil if disabled)
+	history *History
+
+	// Presence sets of streams
+	presence *Presence
+
+	// Called on local presence changes to share them with other nodes
+	presenceNotifier PresenceNotifier
 }
 
-// NewHub builds new hub instance
+// PresenceNotifier is called with the event type ("join" or "leave") and the corresponding member
+// when the local state of a presence set has changed
+type PresenceNotifier = func(event string, record *common.PresenceRecord)
+
+// StreamsNotifier is called when a stream gets the first local subscriber (active is true)
+// or loses the last one (active is false).
+// It's called synchronously under the hub locks, so it must not block.
+type StreamsNotifier = func(stream string, active bool)
+
+// DefaultShardsNum is the default number of hub shards
+const DefaultShardsNum = 16
+
+// How often to remove expired messages from the history
+const historyExpireInterval = 5 * time.Second
+
+// ErrHistoryDisabled is returned when requesting history from a hub without history
+var ErrHistoryDisabled = errors.New("History is disabled")
+
+// NewHub builds new hub instance with the default number of shards
 func NewHub(poolSize int) *Hub {
+	return NewShardedHub(poolSize, DefaultShardsNum)
+}
+
+// NewShardedHub builds new hub instance with the specified number of shards
+func NewShardedHub(poolSize int, shardsNum int) *Hub {
+	if shardsNum < 1 {
+		shardsNum = 1
+	}
+
+	shards := make([]*hubShard, shardsNum)
+
+	for i := range shards {
+		shards[i] = newHubShard()
+	}
+
+	pool := utils.NewGoPool("broadcast", poolSize)
+
 	return &Hub{
-		broadcast:       make(chan *common.StreamMessage, 256),
-		disconnect:      make(chan *common.RemoteDisconnectMessage, 128),
-		register:        make(chan HubRegistration, 2048),
-		subscribe:       make(chan HubSubscription, 128),
-		sessions:        make(map[string]HubSession),
-		identifiers:     make(map[string]map[string]bool),
-		streams:         make(map[string]map[string]map[string]bool),
-		sessionsStreams: make(map[string]map[string][]string),
-		shutdown:        make(chan struct{}),
-		log:             log.WithFields(log.Fields{"context": "hub"}),
-		pool:            utils.NewGoPool("broadcast", poolSize),
+		shards:           shards,
+		patterns:         newStreamPatterns(),
+		shutdown:         make(chan struct{}),
+		log:              log.WithFields(log.Fields{"context": "hub"}),
+		pool:             pool,
+		streamsScheduler: utils.NewKeyedScheduler(pool),
+		presence:         NewPresence(presenceTTL),
 	}
 }
 
+// EnableHistory turns on keeping recent stream messages in memory.
+// Must be called before Run.
+func (h *Hub) EnableHistory(limit int, ttl time.Duration) {
+	h.history = NewHistory(limit, ttl)
+}
+
 // Run makes hub active
 func (h *Hub) Run() {
-	h.done.Add(1)
+	h.shutdownMu.Lock()
+
+	if h.closed {
+		h.shutdownMu.Unlock()
+		return
+	}
+
+	h.done.Add(len(h.shards) + 1)
+	h.shutdownMu.Unlock()
+
+	for _, shard := range h.shards {
+		go h.runShard(shard)
+	}
+
+	var expireHistory <-chan time.Time
+
+	if h.history != nil {
+		ticker := time.NewTicker(historyExpireInterval)
+		defer ticker.Stop()
+
+		expireHistory = ticker.C
+	}
+
+	expirePresence := time.NewTicker(PresenceSyncInterval)
+	defer expirePresence.Stop()
+
+	for {
+		select {
+		case <-expireHistory:
+			h.history.Expire()
+
+		case <-expirePresence.C:
+			h.applyPresenceUpdates(h.presence.Expire())
+
+		case <-h.shutdown:
+			h.done.Done()
+			return
+		}
+	}
+}
+
+func (h *Hub) runShard(shard *hubShard) {
 	for {
 		select {
-		case r := <-h.register:
+		case r := <-shard.register:
 			if r.event == "add" {
 				h.AddSession(r.session)
 			} else {
 				h.RemoveSession(r.session)
 			}
 
-		case subinfo := <-h.subscribe:
+		case subinfo := <-shard.subscribe:
 			switch subinfo.event {
 			case "add":
 				{
@@ -114,7 +186,7 @@ func (h *Hub) Run() {
 				}
 			case "removeAll":
 				{
-					h.unsubscribeSessionFromChannel(subinfo.session, subinfo.identifier, false)
+					h.UnsubscribeSessionFromChannel(subinfo.session, subinfo.identifier)
 				}
 			default:
 				{
@@ -122,12 +194,9 @@ func (h *Hub) Run() {
 				}
 			}
 
-		case message := <-h.broadcast:
+		case message := <-shard.broadcast:
 			h.broadcastToStream(message)
 
-		case command := <-h.disconnect:
-			h.disconnectSessions(command.Identifier, command.Reconnect)
-
 		case <-h.shutdown:
 			h.done.Done()
 			return
@@ -137,27 +206,43 @@ func (h *Hub) Run() {
 
 // RemoveSession enqueues session un-registration
 func (h *Hub) RemoveSessionLater(s HubSession) {
-	h.register <- HubRegistration{event: "remove", session: s}
+	h.shardFor(s.GetID()).register <- HubRegistration{event: "remove", session: s}
 }
 
 // Broadcast enqueues data broadcasting to a stream
 func (h *Hub) Broadcast(stream string, data string) {
-	h.broadcast <- &common.StreamMessage{Stream: stream, Data: data}
+	h.shardFor(stream).broadcast <- &common.StreamMessage{Stream: stream, Data: data}
 }
 
 // BroadcastMessage enqueues broadcasting a pre-built StreamMessage
 func (h *Hub) BroadcastMessage(msg *common.StreamMessage) {
-	h.broadcast <- msg
+	h.shardFor(msg.Stream).broadcast <- msg
 }
 
-// RemoteDisconnect enqueues remote disconnect command
-func (h *Hub) RemoteDisconnect(msg *common.RemoteDisconnectMessage) {
-	h.disconnect <- msg
+// RemoteDisconnect disconnects the sessions matching all the specified selectors
+// (identifiers, session ID, stream and identifiers attributes).
+// Returns the number of sessions to disconnect.
+func (h *Hub) RemoteDisconnect(msg *common.RemoteDisconnectMessage) int {
+	sids := h.selectSessions(msg)
+
+	if len(sids) == 0 {
+		h.log.Debugf("No sessions to disconnect: %v", msg)
+		return 0
+	}
+
+	return h.disconnectSessions(sids, msg.Reconnect)
 }
 
 // Shutdown sends shutdown command to hub
 func (h *Hub) Shutdown() {
-	h.shutdown <- struct{}{}
+	h.shutdownMu.Lock()
+
+	if !h.closed {
+		h.closed = true
+		close(h.shutdown)
+	}
+
+	h.shutdownMu.Unlock()
 
 	// Wait for stop listening channels
 	h.done.Wait()
@@ -165,43 +250,48 @@ func (h *Hub) Shutdown() {
 
 // Size returns a number of active sessions
 func (h *Hub) Size() int {
-	h.sessionsMu.RLock()
-	defer h.sessionsMu.RUnlock()
+	size := 0
+
+	for _, shard := range h.shards {
+		size += shard.size()
+	}
 
-	return len(h.sessions)
+	return size
 }
 
 // UniqSize returns a number of uniq identifiers
 func (h *Hub) UniqSize() int {
-	h.sessionsMu.RLock()
-	defer h.sessionsMu.RUnlock()
+	size := 0
 
-	return len(h.identifiers)
+	for _, shard := range h.shards {
+		size += shard.uniqSize()
+	}
+
+	return size
 }
 
 // StreamsSize returns a number of uniq streams
 func (h *Hub) StreamsSize() int {
-	h.streamsMu.RLock()
-	defer h.streamsMu.RUnlock()
+	size := 0
+
+	for _, shard := range h.shards {
+		size += shard.streamsSize()
+	}
 
-	return len(h.streams)
+	return size
 }
 
 func (h *Hub) AddSession(session HubSession) {
-	h.sessionsMu.Lock()
-	defer h.sessionsMu.Unlock()
-
 	uid := session.GetID()
 	identifiers := session.GetIdentifiers()
 
-	h.sessions[uid] = session
+	h.shardFor(uid).addSession(uid, session)
+	h.shardFor(identifiers).addIdentifiers(identifiers, uid)
 
-	if _, ok := h.identifiers[identifiers]; !ok {
-		h.identifiers[identifiers] = make(map[string]bool)
+	for _, attr := range identifiersAttributes(identifiers) {
+		h.shardFor(attr).addAttribute(attr, uid)
 	}
 
-	h.identifiers[identifiers][uid] = true
-
 	h.log.WithField("sid", uid).Debugf(
 		"Registered with identifiers: %s",
 		identifiers,
@@ -209,69 +299,42 @@ func (h *Hub) AddSession(session HubSession) {
 }
 
 func (h *Hub) RemoveSession(session HubSession) {
-	h.sessionsMu.RLock()
 	uid := session.GetID()
+	shard := h.shardFor(uid)
 
-	if _, ok := h.sessions[uid]; !ok {
-		h.sessionsMu.RUnlock()
+	if _, ok := shard.session(uid); !ok {
 		h.log.WithField("sid", uid).Warn("Session hasn't been registered")
 		return
 	}
-	h.sessionsMu.RUnlock()
 
 	identifiers := session.GetIdentifiers()
 	h.unsubscribeSessionFromAllChannels(uid)
+	h.applyPresenceUpdates(h.presence.RemoveSession(uid))
 
-	h.sessionsMu.Lock()
-
-	delete(h.sessions, uid)
-	delete(h.identifiers[identifiers], uid)
+	shard.removeSession(uid)
+	h.shardFor(identifiers).removeIdentifiers(identifiers, uid)
 
-	if len(h.identifiers[identifiers]) == 0 {
-		delete(h.identifiers, identifiers)
+	for _, attr := range identifiersAttributes(identifiers) {
+		h.shardFor(attr).removeAttribute(attr, uid)
 	}
 
-	h.sessionsMu.Unlock()
-
 	h.log.WithField("sid", uid).Debug("Unregistered")
 }
 
 func (h *Hub) unsubscribeSessionFromAllChannels(sid string) {
-	h.streamsMu.Lock()
-	defer h.streamsMu.Unlock()
-
-	for channel := range h.sessionsStreams[sid] {
-		h.unsubscribeSessionFromChannel(sid, channel, true)
+	for _, shard := range h.shards {
+		shard.unsubscribeSessionFromAllChannels(sid)
 	}
 
-	delete(h.sessionsStreams, sid)
+	h.patterns.unsubscribeSessionFromAllChannels(sid)
 }
 
 func (h *Hub) UnsubscribeSessionFromChannel(sid string, identifier string) {
-	h.unsubscribeSessionFromChannel(sid, identifier, false)
-}
-
-func (h *Hub) unsubscribeSessionFromChannel(sid string, identifier string, locked bool) {
-	if !locked {
-		h.streamsMu.Lock()
-		defer h.streamsMu.Unlock()
-	}
-
-	if _, ok := h.sessionsStreams[sid]; !ok {
-		return
+	for _, shard := range h.shards {
+		shard.unsubscribeSessionFromChannel(sid, identifier, false)
 	}
 
-	for _, stream := range h.sessionsStreams[sid][identifier] {
-		delete(h.streams[stream][sid], identifier)
-
-		if len(h.streams[stream][sid]) == 0 {
-			delete(h.streams[stream], sid)
-		}
-
-		if len(h.streams[stream]) == 0 {
-			delete(h.streams, stream)
-		}
-	}
+	h.patterns.unsubscribeSessionFromChannel(sid, identifier)
 
 	h.log.WithFields(log.Fields{
 		"sid":     sid,
@@ -279,29 +342,15 @@ func (h *Hub) unsubscribeSessionFromChannel(sid string, identifier string, locke
 	}).Debug("Unsubscribed")
 }
 
+// SubscribeSession subscribes the session to the stream.
+// Streams with wildcards (e.g., "orders:*") are treated as patterns.
 func (h *Hub) SubscribeSession(sid string, stream string, identifier string) {
-	h.streamsMu.Lock()
-	defer h.streamsMu.Unlock()
-
-	if _, ok := h.streams[stream]; !ok {
-		h.streams[stream] = make(map[string]map[string]bool)
-	}
-
-	if _, ok := h.streams[stream][sid]; !ok {
-		h.streams[stream][sid] = make(map[string]bool)
-	}
-
-	h.streams[stream][sid][identifier] = true
-
-	if _, ok := h.sessionsStreams[sid]; !ok {
-		h.sessionsStreams[sid] = make(map[string][]string)
+	if IsStreamPattern(stream) {
+		h.patterns.subscribe(sid, stream, identifier)
+	} else {
+		h.shardFor(stream).subscribeSession(sid, stream, identifier)
 	}
 
-	h.sessionsStreams[sid][identifier] = append(
-		h.sessionsStreams[sid][identifier],
-		stream,
-	)
-
 	h.log.WithFields(log.Fields{
 		"sid":     sid,
 		"channel": identifier,
@@ -310,29 +359,14 @@ func (h *Hub) SubscribeSession(sid string, stream string, identifier string) {
 }
 
 func (h *Hub) UnsubscribeSession(sid string, stream string, identifier string) {
-	h.streamsMu.RLock()
-	if _, ok := h.streams[stream]; !ok {
-		h.streamsMu.RUnlock()
-		return
-	}
-
-	if _, ok := h.streams[stream][sid]; !ok {
-		h.streamsMu.RUnlock()
-		return
-	}
-
-	if _, ok := h.streams[stream][sid][identifier]; !ok {
-		h.streamsMu.RUnlock()
+	if IsStreamPattern(stream) {
+		if !h.patterns.unsubscribe(sid, stream, identifier) {
+			return
+		}
+	} else if !h.shardFor(stream).unsubscribeSession(sid, stream, identifier) {
 		return
 	}
 
-	h.streamsMu.RUnlock()
-
-	h.streamsMu.Lock()
-	defer h.streamsMu.Unlock()
-
-	delete(h.streams[stream][sid], identifier)
-
 	h.log.WithFields(log.Fields{
 		"sid":     sid,
 		"channel": identifier,
@@ -347,27 +381,40 @@ func (h *Hub) broadcastToStream(streamMsg *common.StreamMessage) {
 
 	ctx.Debugf("Broadcast message: %v", streamMsg)
 
-	h.streamsMu.RLock()
-	if _, ok := h.streams[stream]; !ok {
+	// Messages must be stored even if there are no sessions at the moment:
+	// they could be requested by reconnected clients
+	if h.history != nil && !streamMsg.Ephemeral {
+		h.history.Add(streamMsg)
+	}
+
+	if !h.shardFor(stream).hasStream(stream) && h.patterns.isEmpty() {
 		ctx.Debug("No sessions")
-		h.streamsMu.RUnlock()
 		return
 	}
-	h.streamsMu.RUnlock()
 
-	h.pool.Schedule(func() {
+	h.sendToStream(stream, streamMsg.ExcludeSocket, func(identifier string) encoders.EncodedMessage {
+		return buildMessage(streamMsg, identifier)
+	})
+}
+
+// sendToStream sends a message to all the stream subscribers (except the specified session).
+// The message is built once per channel identifier.
+// Messages to the same stream are delivered in the order they were sent; different streams are processed concurrently.
+func (h *Hub) sendToStream(stream string, exceptSid string, build func(identifier string) encoders.EncodedMessage) {
+	h.streamsScheduler.Schedule(stream, func() {
 		buf := make(map[string](encoders.EncodedMessage))
 
 		var bdata encoders.EncodedMessage
 
-		h.streamsMu.RLock()
-		streamSessions := streamSessionsSnapshot(h.streams[stream])
-		h.streamsMu.RUnlock()
+		streamSessions := h.shardFor(stream).streamSessions(stream)
+		h.patterns.match(stream, streamSessions)
 
 		for sid, ids := range streamSessions {
-			h.sessionsMu.RLock()
-			session, ok := h.sessions[sid]
-			h.sessionsMu.RUnlock()
+			if sid == exceptSid {
+				continue
+			}
+
+			session, ok := h.shardFor(sid).session(sid)
 
 			if !ok {
 				continue
@@ -377,7 +424,7 @@ func (h *Hub) broadcastToStream(streamMsg *common.StreamMessage) {
 				if msg, ok := buf[id]; ok {
 					bdata = msg
 				} else {
-					bdata = buildMessage(streamMsg, id)
+					bdata = build(id)
 					buf[id] = bdata
 				}
 
@@ -387,42 +434,178 @@ func (h *Hub) broadcastToStream(streamMsg *common.StreamMessage) {
 	})
 }
 
-func (h *Hub) disconnectSessions(identifier string, reconnect bool) {
-	h.sessionsMu.RLock()
-	ids, ok := h.identifiers[identifier]
-	h.sessionsMu.RUnlock()
+// selectSessions returns IDs of the sessions matching all the selectors of the message
+func (h *Hub) selectSessions(msg *common.RemoteDisconnectMessage) []string {
+	// nil means that no selectors have been applied yet
+	var matched map[string]bool
 
-	if !ok {
-		h.log.Debugf("Can not disconnect sessions: unknown identifier %s", identifier)
-		return
+	filter := func(sids []string) {
+		next := make(map[string]bool, len(sids))
+
+		for _, sid := range sids {
+			if matched == nil || matched[sid] {
+				next[sid] = true
+			}
+		}
+
+		matched = next
+	}
+
+	if msg.Identifier != "" {
+		sids, _ := h.shardFor(msg.Identifier).sessionsFor(msg.Identifier)
+		filter(sids)
+	}
+
+	if msg.SessionID != "" {
+		sids := []string{}
+
+		if _, ok := h.shardFor(msg.SessionID).session(msg.SessionID); ok {
+			sids = append(sids, msg.SessionID)
+		}
+
+		filter(sids)
+	}
+
+	if msg.Stream != "" {
+		streamSessions := h.shardFor(msg.Stream).streamSessions(msg.Stream)
+		h.patterns.match(msg.Stream, streamSessions)
+
+		sids := make([]string, 0, len(streamSessions))
+
+		for sid := range streamSessions {
+			sids = append(sids, sid)
+		}
+
+		filter(sids)
+	}
+
+	for name, value := range msg.Attributes {
+		attr := attributeKey(name, value)
+		filter(h.shardFor(attr).sessionsForAttribute(attr))
+	}
+
+	sids := make([]string, 0, len(matched))
+
+	for sid := range matched {
+		sids = append(sids, sid)
+	}
+
+	sort.Strings(sids)
+
+	return sids
+}
+
+func (h *Hub) disconnectSessions(sids []string, reconnect bool) int {
+	sessions := make([]HubSession, 0, len(sids))
+
+	for _, sid := range sids {
+		if ses, ok := h.shardFor(sid).session(sid); ok {
+			sessions = append(sessions, ses)
+		}
 	}
 
 	msg := common.NewDisconnectMessage(common.REMOTE_DISCONNECT_REASON, reconnect)
 
 	h.pool.Schedule(func() {
-		h.sessionsMu.RLock()
-		defer h.sessionsMu.RUnlock()
+		for _, ses := range sessions {
+			ses.DisconnectWithMessage(msg, common.REMOTE_DISCONNECT_REASON)
+		}
+	})
 
-		for id := range ids {
-			if ses, ok := h.sessions[id]; ok {
-				ses.DisconnectWithMessage(msg, common.REMOTE_DISCONNECT_REASON)
-			}
+	return len(sessions)
+}
+
+// HistoryFrom returns the stream messages following the specified position
+func (h *Hub) HistoryFrom(stream string, epoch string, offset uint64) ([]*common.StreamMessage, error) {
+	if h.history == nil {
+		return nil, ErrHistoryDisabled
+	}
+
+	return h.history.From(stream, epoch, offset)
+}
+
+// HistorySince returns the stream messages published since the specified time (Unix seconds)
+func (h *Hub) HistorySince(stream string, since int64) ([]*common.StreamMessage, error) {
+	if h.history == nil {
+		return nil, ErrHistoryDisabled
+	}
+
+	return h.history.Since(stream, since), nil
+}
+
+// OnPresenceChange sets the callback to share local presence changes with other nodes.
+// Must be called before Run.
+func (h *Hub) OnPresenceChange(fn PresenceNotifier) {
+	h.presenceNotifier = fn
+}
+
+// OnStreamsChange sets the callback to track streams with local subscribers
+// (e.g., to subscribe to stream-specific pub/sub channels).
+// Stream patterns are not reported.
+// Must be called before Run.
+func (h *Hub) OnStreamsChange(fn StreamsNotifier) {
+	for _, shard := range h.shards {
+		shard.notifyStream = fn
+	}
+}
+
+// PresenceJoin adds the session to the stream presence set and notifies other subscribers
+func (h *Hub) PresenceJoin(sid string, stream string, id string, info interface{}) {
+	h.applyPresenceUpdates(h.presence.Join(sid, stream, id, info))
+}
+
+// PresenceLeave removes the session from the stream presence set and notifies other subscribers
+func (h *Hub) PresenceLeave(sid string, stream string) {
+	h.applyPresenceUpdates(h.presence.Leave(sid, stream))
+}
+
+// PresenceMembers returns the current members of the stream presence set
+func (h *Hub) PresenceMembers(stream string) []*common.PresenceRecord {
+	return h.presence.Members(stream)
+}
+
+// LocalPresence returns the members joined via this hub sessions
+func (h *Hub) LocalPresence() []*common.PresenceRecord {
+	return h.presence.Local()
+}
+
+// HandleRemotePresence applies presence changes received from another node
+func (h *Hub) HandleRemotePresence(msg *common.RemotePresenceMessage) {
+	h.applyPresenceUpdates(h.presence.HandleRemote(msg))
+}
+
+func (h *Hub) applyPresenceUpdates(updates []*presenceUpdate) {
+	for _, update := range updates {
+		if update.notify {
+			h.broadcastPresence(update.stream, update.event, update.sid)
 		}
+
+		if update.share && h.presenceNotifier != nil {
+			h.presenceNotifier(
+				update.event.Type,
+				&common.PresenceRecord{Stream: update.stream, ID: update.event.ID, Info: update.event.Info},
+			)
+		}
+	}
+}
+
+func (h *Hub) broadcastPresence(stream string, event *common.PresenceEvent, exceptSid string) {
+	h.log.WithField("stream", stream).Debugf("Presence event: %v", event)
+
+	h.sendToStream(stream, exceptSid, func(identifier string) encoders.EncodedMessage {
+		return encoders.NewCachedEncodedMessage(&common.Reply{Type: common.PresenceType, Identifier: identifier, Message: event})
 	})
 }
 
 func (h *Hub) FindByIdentifier(id string) HubSession {
-	h.sessionsMu.RLock()
-	defer h.sessionsMu.RUnlock()
-
-	ids, ok := h.identifiers[id]
+	sids, ok := h.shardFor(id).sessionsFor(id)
 
 	if !ok {
 		return nil
 	}
 
-	for id := range ids {
-		if ses, ok := h.sessions[id]; ok {
+	for _, sid := range sids {
+		if ses, ok := h.shardFor(sid).session(sid); ok {
 			return ses
 		}
 	}
@@ -430,12 +613,102 @@ func (h *Hub) FindByIdentifier(id string) HubSession {
 	return nil
 }
 
+// FindAllByIdentifier returns all the sessions with the specified identifiers (sorted by ID)
+func (h *Hub) FindAllByIdentifier(id string) []HubSession {
+	sids, ok := h.shardFor(id).sessionsFor(id)
+
+	if !ok {
+		return nil
+	}
+
+	sort.Strings(sids)
+
+	sessions := make([]HubSession, 0, len(sids))
+
+	for _, sid := range sids {
+		if ses, ok := h.shardFor(sid).session(sid); ok {
+			sessions = append(sessions, ses)
+		}
+	}
+
+	return sessions
+}
+
+// FindBySessionID returns the session with the specified ID (or nil)
+func (h *Hub) FindBySessionID(sid string) HubSession {
+	if ses, ok := h.shardFor(sid).session(sid); ok {
+		return ses
+	}
+
+	return nil
+}
+
+// SessionIDs returns the IDs of all the registered sessions (sorted)
+func (h *Hub) SessionIDs() []string {
+	sids := make([]string, 0, h.Size())
+
+	for _, shard := range h.shards {
+		shard.sessionsMu.RLock()
+		for sid := range shard.sessions {
+			sids = append(sids, sid)
+		}
+		shard.sessionsMu.RUnlock()
+	}
+
+	sort.Strings(sids)
+
+	return sids
+}
+
+// StreamInfo contains the stream name and the number of subscribed sessions
+type StreamInfo struct {
+	Stream   string `json:"stream"`
+	Sessions int    `json:"sessions"`
+}
+
+// Streams returns the information about all the active streams (sorted by name).
+// Pattern subscriptions are not taken into account.
+func (h *Hub) Streams() []*StreamInfo {
+	streams := make([]*StreamInfo, 0, h.StreamsSize())
+
+	for _, shard := range h.shards {
+		shard.streamsMu.RLock()
+		for stream, sessions := range shard.streams {
+			streams = append(streams, &StreamInfo{Stream: stream, Sessions: len(sessions)})
+		}
+		shard.streamsMu.RUnlock()
+	}
+
+	sort.Slice(streams, func(i, j int) bool { return streams[i].Stream < streams[j].Stream })
+
+	return streams
+}
+
 func (h *Hub) DisconnectSesssions(msg encoders.EncodedMessage, code string) {
-	h.sessionsMu.RLock()
-	for _, session := range h.sessions {
-		session.DisconnectWithMessage(msg, code)
+	for _, shard := range h.shards {
+		shard.sessionsMu.RLock()
+		for _, session := range shard.sessions {
+			session.DisconnectWithMessage(msg, code)
+		}
+		shard.sessionsMu.RUnlock()
 	}
-	h.sessionsMu.RUnlock()
+}
+
+// shardFor returns the shard responsible for the key (stream name, session ID or identifiers)
+func (h *Hub) shardFor(key string) *hubShard {
+	if len(h.shards) == 1 {
+		return h.shards[0]
+	}
+
+	// FNV-1a
+	var hash uint32 = 2166136261
+
+	for i := 0; i < len(key); i++ {
+		hash ^= uint32(key[i])
+		hash *= 16777619
+	}
+
+	return h.shards[hash%uint32(len(h.shards))]
 }
 
 func buildMessage(msg *common.StreamMessage, identifier string) encoders.EncodedMessage {
@@ -6,19 +6,22 @@ import (
 	"fmt"
 	"math/rand"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/anycable/anycable-go/common"
 	"github.com/anycable/anycable-go/encoders"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type MockSession struct {
-	sid      string
-	incoming chan ([]byte)
-	closed   bool
-	closeMu  sync.Mutex
+	sid         string
+	identifiers string
+	incoming    chan ([]byte)
+	closed      bool
+	closeMu     sync.Mutex
 }
 
 func (s *MockSession) GetID() string {
@@ -26,6 +29,10 @@ func (s *MockSession) GetID() string {
 }
 
 func (s *MockSession) GetIdentifiers() string {
+	if s.identifiers != "" {
+		return s.identifiers
+	}
+
 	return s.sid
 }
 
@@ -126,6 +133,60 @@ func TestUnsubscribeRaceConditions(t *testing.T) {
 	assert.Equal(t, 1, hub.Size(), "Connections size must be equal 1")
 }
 
+func TestShardedHubStats(t *testing.T) {
+	hub := NewShardedHub(2, 4)
+
+	go hub.Run()
+	defer hub.Shutdown()
+
+	for i := 0; i < 10; i++ {
+		session := NewMockSession(fmt.Sprintf("%d", i))
+		hub.AddSession(session)
+		hub.SubscribeSession(session.GetID(), fmt.Sprintf("stream_%d", i%5), "test_channel")
+	}
+
+	assert.Equal(t, 10, hub.Size())
+	assert.Equal(t, 10, hub.UniqSize())
+	assert.Equal(t, 5, hub.StreamsSize())
+
+	hub.RemoveSession(NewMockSession("0"))
+	hub.RemoveSession(NewMockSession("5"))
+
+	assert.Equal(t, 8, hub.Size())
+	assert.Equal(t, 8, hub.UniqSize())
+	assert.Equal(t, 4, hub.StreamsSize())
+	assert.NotNil(t, hub.FindByIdentifier("1"))
+	assert.Nil(t, hub.FindByIdentifier("5"))
+}
+
+func TestHubIntrospection(t *testing.T) {
+	hub := NewShardedHub(2, 4)
+
+	go hub.Run()
+	defer hub.Shutdown()
+
+	for i := 0; i < 5; i++ {
+		session := NewMockSession(fmt.Sprintf("%d", i))
+		hub.AddSession(session)
+		hub.SubscribeSession(session.GetID(), fmt.Sprintf("stream_%d", i%2), "test_channel")
+	}
+
+	assert.Equal(t, []string{"0", "1", "2", "3", "4"}, hub.SessionIDs())
+
+	assert.Equal(t, []*StreamInfo{
+		{Stream: "stream_0", Sessions: 3},
+		{Stream: "stream_1", Sessions: 2},
+	}, hub.Streams())
+
+	assert.Equal(t, "3", hub.FindBySessionID("3").GetID())
+	assert.Nil(t, hub.FindBySessionID("42"))
+
+	sessions := hub.FindAllByIdentifier("2")
+	require.Len(t, sessions, 1)
+	assert.Equal(t, "2", sessions[0].GetID())
+	assert.Empty(t, hub.FindAllByIdentifier("42"))
+}
+
 func TestUnsubscribeSession(t *testing.T) {
 	hub := NewHub(2)
 
@@ -205,6 +266,92 @@ func TestSubscribeSession(t *testing.T) {
 	})
 }
 
+func TestStreamsNotifications(t *testing.T) {
+	hub := NewHub(2)
+
+	events := []string{}
+
+	hub.OnStreamsChange(func(stream string, active bool) {
+		events = append(events, fmt.Sprintf("%s:%v", stream, active))
+	})
+
+	session := NewMockSession("123")
+	session2 := NewMockSession("321")
+	hub.AddSession(session)
+	hub.AddSession(session2)
+
+	hub.SubscribeSession("123", "test", "test_channel")
+	hub.SubscribeSession("321", "test", "test_channel")
+	hub.SubscribeSession("321", "test", "test_channel_2")
+	hub.SubscribeSession("123", "test2", "test_channel")
+	hub.SubscribeSession("123", "test:*", "test_channel")
+
+	assert.Equal(t, []string{"test:true", "test2:true"}, events)
+
+	hub.UnsubscribeSession("123", "test", "test_channel")
+	hub.UnsubscribeSession("321", "test", "test_channel")
+
+	assert.Equal(t, []string{"test:true", "test2:true"}, events)
+
+	hub.UnsubscribeSessionFromChannel("321", "test_channel_2")
+
+	assert.Equal(t, []string{"test:true", "test2:true", "test:false"}, events)
+
+	hub.RemoveSession(session)
+
+	assert.Equal(t, []string{"test:true", "test2:true", "test:false", "test2:false"}, events)
+	assert.Equal(t, 0, hub.StreamsSize())
+}
+
+func TestPatternSubscriptions(t *testing.T) {
+	hub := NewHub(2)
+
+	go hub.Run()
+	defer hub.Shutdown()
+
+	session := NewMockSession("123")
+	hub.AddSession(session)
+
+	hub.SubscribeSession("123", "orders:*", "dashboard")
+	hub.SubscribeSession("123", "orders:42", "order")
+
+	t.Run("Broadcast to the stream matching the pattern", func(t *testing.T) {
+		hub.Broadcast("orders:1", "\"created\"")
+
+		msg, err := session.Read()
+		assert.Nil(t, err)
+		assert.Equal(t, "{\"identifier\":\"dashboard\",\"message\":\"created\"}", string(msg))
+	})
+
+	t.Run("Broadcast to the stream matching both the pattern and the exact stream", func(t *testing.T) {
+		hub.Broadcast("orders:42", "\"paid\"")
+
+		received := []string{}
+
+		for i := 0; i < 2; i++ {
+			msg, err := session.Read()
+			assert.Nil(t, err)
+			received = append(received, string(msg))
+		}
+
+		assert.Contains(t, received, "{\"identifier\":\"dashboard\",\"message\":\"paid\"}")
+		assert.Contains(t, received, "{\"identifier\":\"order\",\"message\":\"paid\"}")
+	})
+
+	t.Run("Patterns are not counted as streams", func(t *testing.T) {
+		assert.Equal(t, 1, hub.StreamsSize())
+	})
+
+	t.Run("Unsubscribe from the pattern", func(t *testing.T) {
+		hub.UnsubscribeSessionFromChannel("123", "dashboard")
+
+		hub.Broadcast("orders:1", "\"updated\"")
+
+		_, err := session.Read()
+		assert.NotNil(t, err)
+	})
+}
+
 func TestRemoteDisconnect(t *testing.T) {
 	hub := NewHub(2)
 
@@ -215,7 +362,8 @@ func TestRemoteDisconnect(t *testing.T) {
 	hub.AddSession(session)
 
 	t.Run("Disconnect session", func(t *testing.T) {
-		hub.RemoteDisconnect(&common.RemoteDisconnectMessage{Identifier: "123", Reconnect: false})
+		count := hub.RemoteDisconnect(&common.RemoteDisconnectMessage{Identifier: "123", Reconnect: false})
+		assert.Equal(t, 1, count)
 
 		msg, err := session.Read()
 		assert.Nil(t, err)
@@ -225,6 +373,73 @@ func TestRemoteDisconnect(t *testing.T) {
 	})
 }
 
+func TestRemoteDisconnectSelectors(t *testing.T) {
+	hub := NewShardedHub(2, 4)
+
+	go hub.Run()
+	defer hub.Shutdown()
+
+	jack := &MockSession{sid: "s1", identifiers: `{"user_id":42,"team":"a"}`, incoming: make(chan []byte, 256)}
+	jackMobile := &MockSession{sid: "s2", identifiers: `{"user_id":42,"team":"a"}`, incoming: make(chan []byte, 256)}
+	john := &MockSession{sid: "s3", identifiers: `{"user_id":"43","team":"a"}`, incoming: make(chan []byte, 256)}
+	anonymous := &MockSession{sid: "s4", identifiers: "anonymous", incoming: make(chan []byte, 256)}
+
+	for _, s := range []*MockSession{jack, jackMobile, john, anonymous} {
+		hub.AddSession(s)
+	}
+
+	hub.SubscribeSession("s1", "chat_1", "chat")
+	hub.SubscribeSession("s3", "chat_1", "chat")
+	hub.SubscribeSession("s4", "chat_*", "all_chats")
+
+	selectIDs := func(msg *common.RemoteDisconnectMessage) []string {
+		return hub.selectSessions(msg)
+	}
+
+	t.Run("No selectors", func(t *testing.T) {
+		assert.Empty(t, selectIDs(&common.RemoteDisconnectMessage{}))
+	})
+
+	t.Run("By session ID", func(t *testing.T) {
+		assert.Equal(t, []string{"s2"}, selectIDs(&common.RemoteDisconnectMessage{SessionID: "s2"}))
+		assert.Empty(t, selectIDs(&common.RemoteDisconnectMessage{SessionID: "s42"}))
+	})
+
+	t.Run("By stream", func(t *testing.T) {
+		assert.Equal(t, []string{"s1", "s3", "s4"}, selectIDs(&common.RemoteDisconnectMessage{Stream: "chat_1"}))
+	})
+
+	t.Run("By attributes", func(t *testing.T) {
+		assert.Equal(t, []string{"s1", "s2"}, selectIDs(&common.RemoteDisconnectMessage{Attributes: map[string]string{"user_id": "42"}}))
+		assert.Equal(t, []string{"s3"}, selectIDs(&common.RemoteDisconnectMessage{Attributes: map[string]string{"user_id": "43", "team": "a"}}))
+		assert.Empty(t, selectIDs(&common.RemoteDisconnectMessage{Attributes: map[string]string{"user_id": "43", "team": "b"}}))
+	})
+
+	t.Run("Combined selectors", func(t *testing.T) {
+		assert.Equal(t, []string{"s1"}, selectIDs(&common.RemoteDisconnectMessage{Stream: "chat_1", Attributes: map[string]string{"user_id": "42"}}))
+	})
+
+	t.Run("Disconnect by attributes", func(t *testing.T) {
+		count := hub.RemoteDisconnect(&common.RemoteDisconnectMessage{Attributes: map[string]string{"user_id": "42"}})
+		assert.Equal(t, 2, count)
+
+		for _, s := range []*MockSession{jack, jackMobile} {
+			_, err := s.Read()
+			assert.NoError(t, err)
+		}
+
+		_, err := john.Read()
+		assert.Error(t, err)
+	})
+
+	t.Run("Attributes index is cleaned up", func(t *testing.T) {
+		hub.RemoveSession(jack)
+		hub.RemoveSession(jackMobile)
+
+		assert.Empty(t, selectIDs(&common.RemoteDisconnectMessage{Attributes: map[string]string{"user_id": "42"}}))
+	})
+}
+
 func TestBroadcastMessage(t *testing.T) {
 	hub := NewHub(2)
 
@@ -252,6 +467,209 @@ func TestBroadcastMessage(t *testing.T) {
 	})
 }
 
+func TestBroadcastOrdering(t *testing.T) {
+	hub := NewHub(16)
+
+	go hub.Run()
+	defer hub.Shutdown()
+
+	streamsNum := 20
+	sessionsNum := 50
+	messagesNum := 200
+
+	sessions := make([]*MockSession, sessionsNum)
+
+	for i := range sessions {
+		session := &MockSession{sid: fmt.Sprintf("%d", i), incoming: make(chan []byte, streamsNum*messagesNum)}
+		sessions[i] = session
+		hub.AddSession(session)
+
+		for j := 0; j < streamsNum; j++ {
+			hub.SubscribeSession(session.sid, fmt.Sprintf("stream_%d", j), fmt.Sprintf("channel_%d", j))
+		}
+	}
+
+	var wg sync.WaitGroup
+
+	// Publish concurrently to all streams
+	for j := 0; j < streamsNum; j++ {
+		wg.Add(1)
+
+		go func(stream string) {
+			defer wg.Done()
+
+			for i := 0; i < messagesNum; i++ {
+				hub.Broadcast(stream, fmt.Sprintf("%d", i))
+			}
+		}(fmt.Sprintf("stream_%d", j))
+	}
+
+	wg.Wait()
+
+	for _, session := range sessions {
+		last := make(map[string]int)
+
+		for i := 0; i < streamsNum*messagesNum; i++ {
+			msg, err := session.Read()
+			require.NoError(t, err)
+
+			var reply common.Reply
+			require.NoError(t, json.Unmarshal(msg, &reply))
+
+			seq := int(reply.Message.(float64))
+
+			prev, ok := last[reply.Identifier]
+
+			if ok {
+				require.Equalf(t, prev+1, seq, "Messages for %s are out of order for session %s", reply.Identifier, session.sid)
+			} else {
+				require.Equal(t, 0, seq)
+			}
+
+			last[reply.Identifier] = seq
+		}
+	}
+}
+
+func TestBroadcastWithHistory(t *testing.T) {
+	hub := NewHub(2)
+	hub.EnableHistory(10, time.Minute)
+
+	go hub.Run()
+	defer hub.Shutdown()
+
+	session := NewMockSession("123")
+	hub.AddSession(session)
+	hub.SubscribeSession("123", "test", "test_channel")
+
+	hub.Broadcast("test", "\"ciao\"")
+
+	msg, err := session.Read()
+	assert.Nil(t, err)
+
+	var reply common.Reply
+	assert.Nil(t, json.Unmarshal(msg, &reply))
+
+	assert.Equal(t, "test", reply.StreamID)
+	assert.Equal(t, uint64(1), reply.Offset)
+	assert.NotEmpty(t, reply.Epoch)
+
+	t.Run("Stores messages for streams without sessions", func(t *testing.T) {
+		hub.Broadcast("lonely", "\"hola\"")
+		hub.Broadcast("test", "\"ciao\"")
+
+		_, err := session.Read()
+		assert.Nil(t, err)
+
+		// Streams could belong to different shards, so the order of processing is not guaranteed
+		assert.Eventually(t, func() bool {
+			messages, err := hub.HistorySince("lonely", 0)
+			return err == nil && len(messages) == 1
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("Returns history from the position", func(t *testing.T) {
+		messages, err := hub.HistoryFrom("test", reply.Epoch, 1)
+		assert.Nil(t, err)
+		assert.Len(t, messages, 1)
+		assert.Equal(t, uint64(2), messages[0].Offset)
+	})
+
+	t.Run("Doesn't store ephemeral messages", func(t *testing.T) {
+		hub.BroadcastMessage(&common.StreamMessage{Stream: "test", Data: "\"typing\"", Ephemeral: true})
+
+		msg, err := session.Read()
+		assert.Nil(t, err)
+
+		var ephemeral common.Reply
+		assert.Nil(t, json.Unmarshal(msg, &ephemeral))
+
+		assert.Equal(t, "typing", ephemeral.Message)
+		assert.Empty(t, ephemeral.StreamID)
+
+		messages, err := hub.HistoryFrom("test", reply.Epoch, 0)
+		assert.Nil(t, err)
+		assert.Len(t, messages, 2)
+	})
+}
+
+func TestHistoryDisabled(t *testing.T) {
+	hub := NewHub(2)
+
+	_, err := hub.HistorySince("test", 0)
+	assert.Equal(t, ErrHistoryDisabled, err)
+
+	_, err = hub.HistoryFrom("test", "", 0)
+	assert.Equal(t, ErrHistoryDisabled, err)
+}
+
+func TestPresence(t *testing.T) {
+	hub := NewHub(2)
+
+	shared := []string{}
+	var sharedMu sync.Mutex
+
+	hub.OnPresenceChange(func(event string, record *common.PresenceRecord) {
+		sharedMu.Lock()
+		defer sharedMu.Unlock()
+
+		shared = append(shared, fmt.Sprintf("%s:%s:%s", event, record.Stream, record.ID))
+	})
+
+	go hub.Run()
+	defer hub.Shutdown()
+
+	session := NewMockSession("123")
+	hub.AddSession(session)
+	hub.SubscribeSession("123", "test", "test_channel")
+
+	session2 := NewMockSession("321")
+	hub.AddSession(session2)
+	hub.SubscribeSession("321", "test", "test_channel")
+
+	hub.PresenceJoin("123", "test", "42", map[string]string{"name": "Jack"})
+
+	msg, err := session2.Read()
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"type\":\"presence\",\"identifier\":\"test_channel\",\"message\":{\"type\":\"join\",\"id\":\"42\",\"info\":{\"name\":\"Jack\"}}}", string(msg))
+
+	_, err = session.Read()
+	assert.NotNil(t, err, "joined session must not be notified")
+
+	assert.Len(t, hub.PresenceMembers("test"), 1)
+
+	t.Run("Remote members", func(t *testing.T) {
+		hub.HandleRemotePresence(&common.RemotePresenceMessage{
+			Node:    "n2",
+			Event:   common.PresenceJoinType,
+			Records: []*common.PresenceRecord{{Stream: "test", ID: "2022"}},
+		})
+
+		_, err := session.Read()
+		assert.Nil(t, err)
+		_, err = session2.Read()
+		assert.Nil(t, err)
+
+		assert.Len(t, hub.PresenceMembers("test"), 2)
+		assert.Len(t, hub.LocalPresence(), 1)
+	})
+
+	t.Run("Leaves on session removal", func(t *testing.T) {
+		hub.RemoveSession(session)
+
+		msg, err := session2.Read()
+		assert.Nil(t, err)
+		assert.Equal(t, "{\"type\":\"presence\",\"identifier\":\"test_channel\",\"message\":{\"type\":\"leave\",\"id\":\"42\"}}", string(msg))
+
+		assert.Len(t, hub.PresenceMembers("test"), 1)
+	})
+
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+
+	assert.Equal(t, []string{"join:test:42", "leave:test:42"}, shared)
+}
+
 func TestBuildMessageJSON(t *testing.T) {
 	expected := []byte("{\"identifier\":\"chat\",\"message\":{\"text\":\"hello!\"}}")
 	actual := toJSON(buildMessage(&common.StreamMessage{Data: "{\"text\":\"hello!\"}"}, "chat"))
@@ -354,6 +772,53 @@ func BenchmarkBroadcast(b *testing.B) {
 	}
 }
 
+// BenchmarkShardedSubscribeBroadcast measures the throughput of concurrent subscribe/unsubscribe
+// and broadcast lookup operations for different numbers of shards (shards=1 corresponds to a single global lock).
+// Run it with -cpu to see the effect of locks contention, e.g.: go test ./hub -bench Sharded -cpu 1,4,16
+func BenchmarkShardedSubscribeBroadcast(b *testing.B) {
+	streamsNum := 1000
+	sessionsPerStream := 10
+
+	for _, shardsNum := range []int{1, 4, 16, 64} {
+		b.Run(fmt.Sprintf("shards=%d", shardsNum), func(b *testing.B) {
+			hub := NewShardedHub(1, shardsNum)
+
+			streams := make([]string, streamsNum)
+
+			for i := range streams {
+				streams[i] = fmt.Sprintf("stream_%d", i)
+
+				for j := 0; j < sessionsPerStream; j++ {
+					session := NewMockSession(fmt.Sprintf("%d_%d", i, j))
+					hub.AddSession(session)
+					hub.SubscribeSession(session.GetID(), streams[i], "test_channel")
+				}
+			}
+
+			var seed int64
+
+			b.ResetTimer()
+
+			b.RunParallel(func(pb *testing.PB) {
+				n := atomic.AddInt64(&seed, 1)
+				r := rand.New(rand.NewSource(n)) // nolint:gosec
+				sid := fmt.Sprintf("storm_%d", n)
+
+				for pb.Next() {
+					stream := streams[r.Intn(streamsNum)]
+
+					if r.Intn(2) == 0 {
+						hub.SubscribeSession(sid, stream, "storm_channel")
+						hub.UnsubscribeSessionFromChannel(sid, "storm_channel")
+					} else {
+						hub.shardFor(stream).streamSessions(stream)
+					}
+				}
+			})
+		})
+	}
+}
+
 func toJSON(msg encoders.EncodedMessage) []byte {
 	b, err := json.Marshal(&msg)
 	if err != nil {
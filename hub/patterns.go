@@ -0,0 +1,209 @@
+package hub
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// IsStreamPattern returns true if the stream name contains wildcards ("*" or "?")
+func IsStreamPattern(stream string) bool {
+	return strings.ContainsAny(stream, "*?")
+}
+
+// MatchStreamPattern reports whether the stream name matches the glob pattern,
+// where "*" matches any sequence of characters and "?" matches any single character
+func MatchStreamPattern(pattern string, stream string) bool {
+	p, s := 0, 0
+	// Position of the last seen "*" in the pattern and the corresponding position in the stream
+	star, mark := -1, 0
+
+	for s < len(stream) {
+		switch {
+		case p < len(pattern) && (pattern[p] == '?' || pattern[p] == stream[s]):
+			p++
+			s++
+		case p < len(pattern) && pattern[p] == '*':
+			star = p
+			mark = s
+			p++
+		case star != -1:
+			// Let the last "*" consume one more character
+			p = star + 1
+			mark++
+			s = mark
+		default:
+			return false
+		}
+	}
+
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+
+	return p == len(pattern)
+}
+
+// streamPatterns keeps subscriptions to stream patterns (e.g., "orders:*").
+// It's kept separately from the exact streams, so exact lookups are not affected.
+type streamPatterns struct {
+	// pattern -> sid -> identifier -> true
+	patterns map[string]map[string]map[string]bool
+
+	// sid -> identifier -> [pattern]
+	sessionsPatterns map[string]map[string][]string
+
+	// The number of registered patterns (to skip matching without locking when there are none)
+	size int32
+
+	mu sync.RWMutex
+}
+
+func newStreamPatterns() *streamPatterns {
+	return &streamPatterns{
+		patterns:         make(map[string]map[string]map[string]bool),
+		sessionsPatterns: make(map[string]map[string][]string),
+	}
+}
+
+func (sp *streamPatterns) isEmpty() bool {
+	return atomic.LoadInt32(&sp.size) == 0
+}
+
+func (sp *streamPatterns) subscribe(sid string, pattern string, identifier string) {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	if _, ok := sp.patterns[pattern]; !ok {
+		sp.patterns[pattern] = make(map[string]map[string]bool)
+	}
+
+	if _, ok := sp.patterns[pattern][sid]; !ok {
+		sp.patterns[pattern][sid] = make(map[string]bool)
+	}
+
+	sp.patterns[pattern][sid][identifier] = true
+
+	if _, ok := sp.sessionsPatterns[sid]; !ok {
+		sp.sessionsPatterns[sid] = make(map[string][]string)
+	}
+
+	sp.sessionsPatterns[sid][identifier] = append(sp.sessionsPatterns[sid][identifier], pattern)
+
+	sp.updateSize()
+}
+
+func (sp *streamPatterns) unsubscribe(sid string, pattern string, identifier string) bool {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	if _, ok := sp.patterns[pattern][sid][identifier]; !ok {
+		return false
+	}
+
+	sp.remove(sid, pattern, identifier)
+
+	patterns := sp.sessionsPatterns[sid][identifier]
+
+	for i, p := range patterns {
+		if p == pattern {
+			sp.sessionsPatterns[sid][identifier] = append(patterns[:i], patterns[i+1:]...)
+			break
+		}
+	}
+
+	sp.updateSize()
+
+	return true
+}
+
+func (sp *streamPatterns) unsubscribeSessionFromChannel(sid string, identifier string) {
+	if sp.isEmpty() {
+		return
+	}
+
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	for _, pattern := range sp.sessionsPatterns[sid][identifier] {
+		sp.remove(sid, pattern, identifier)
+	}
+
+	delete(sp.sessionsPatterns[sid], identifier)
+
+	if len(sp.sessionsPatterns[sid]) == 0 {
+		delete(sp.sessionsPatterns, sid)
+	}
+
+	sp.updateSize()
+}
+
+func (sp *streamPatterns) unsubscribeSessionFromAllChannels(sid string) {
+	if sp.isEmpty() {
+		return
+	}
+
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	for identifier, patterns := range sp.sessionsPatterns[sid] {
+		for _, pattern := range patterns {
+			sp.remove(sid, pattern, identifier)
+		}
+	}
+
+	delete(sp.sessionsPatterns, sid)
+
+	sp.updateSize()
+}
+
+// match adds sessions subscribed to the patterns matching the stream to the provided
+// stream sessions snapshot (sid -> [identifier])
+func (sp *streamPatterns) match(stream string, dest map[string][]string) {
+	if sp.isEmpty() {
+		return
+	}
+
+	sp.mu.RLock()
+	defer sp.mu.RUnlock()
+
+	for pattern, sessions := range sp.patterns {
+		if !MatchStreamPattern(pattern, stream) {
+			continue
+		}
+
+		for sid, ids := range sessions {
+			for id := range ids {
+				if !containsString(dest[sid], id) {
+					dest[sid] = append(dest[sid], id)
+				}
+			}
+		}
+	}
+}
+
+func (sp *streamPatterns) remove(sid string, pattern string, identifier string) {
+	delete(sp.patterns[pattern][sid], identifier)
+
+	if len(sp.patterns[pattern][sid]) == 0 {
+		delete(sp.patterns[pattern], sid)
+	}
+
+	if len(sp.patterns[pattern]) == 0 {
+		delete(sp.patterns, pattern)
+	}
+}
+
+func (sp *streamPatterns) updateSize() {
+	atomic.StoreInt32(&sp.size, int32(len(sp.patterns)))
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,68 @@
+package hub
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchStreamPattern(t *testing.T) {
+	cases := []struct {
+		pattern string
+		stream  string
+		matches bool
+	}{
+		{"orders:*", "orders:42", true},
+		{"orders:*", "orders:", true},
+		{"orders:*", "orders", false},
+		{"orders:*", "users:42", false},
+		{"*:updates", "orders:42:updates", true},
+		{"orders:*:items:*", "orders:42:items:1", true},
+		{"orders:*:items:*", "orders:42:users:1", false},
+		{"orders:?", "orders:1", true},
+		{"orders:?", "orders:12", false},
+		{"*", "anything", true},
+		{"a*b*c", "aXXbYYc", true},
+		{"a*b*c", "aXXbYY", false},
+	}
+
+	for _, c := range cases {
+		assert.Equalf(t, c.matches, MatchStreamPattern(c.pattern, c.stream), "%s ~ %s", c.pattern, c.stream)
+	}
+}
+
+func TestStreamPatterns(t *testing.T) {
+	patterns := newStreamPatterns()
+
+	assert.True(t, patterns.isEmpty())
+
+	patterns.subscribe("s1", "orders:*", "dashboard")
+	patterns.subscribe("s1", "orders:1*", "dashboard")
+	patterns.subscribe("s2", "orders:*", "dashboard")
+
+	assert.False(t, patterns.isEmpty())
+
+	t.Run("Match returns each identifier once", func(t *testing.T) {
+		sessions := map[string][]string{"s2": {"dashboard"}}
+
+		patterns.match("orders:15", sessions)
+
+		assert.Equal(t, map[string][]string{"s1": {"dashboard"}, "s2": {"dashboard"}}, sessions)
+	})
+
+	t.Run("Unsubscribe", func(t *testing.T) {
+		assert.True(t, patterns.unsubscribe("s2", "orders:*", "dashboard"))
+		assert.False(t, patterns.unsubscribe("s2", "orders:*", "dashboard"))
+
+		sessions := map[string][]string{}
+		patterns.match("orders:15", sessions)
+
+		assert.Equal(t, map[string][]string{"s1": {"dashboard"}}, sessions)
+	})
+
+	t.Run("Unsubscribe from all channels", func(t *testing.T) {
+		patterns.unsubscribeSessionFromAllChannels("s1")
+
+		assert.True(t, patterns.isEmpty())
+	})
+}
@@ -0,0 +1,316 @@
+package hub
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/anycable/anycable-go/common"
+)
+
+// PresenceSyncInterval defines how often nodes share their presence sets members with each other
+const PresenceSyncInterval = 5 * time.Second
+
+// Members reported by other nodes are removed if they haven't been refreshed during this period
+const presenceTTL = 3 * PresenceSyncInterval
+
+type presenceMember struct {
+	info interface{}
+	// Local sessions joined the presence set with this ID
+	sessions map[string]bool
+	// Other nodes reporting this member (node ID -> deadline)
+	nodes map[string]time.Time
+}
+
+func (m *presenceMember) isEmpty() bool {
+	return len(m.sessions) == 0 && len(m.nodes) == 0
+}
+
+// presenceUpdate describes a presence set change
+type presenceUpdate struct {
+	stream string
+	event  *common.PresenceEvent
+	// Session which triggered the update (it's not notified)
+	sid string
+	// Whether the member has appeared or disappeared (so, subscribers must be notified)
+	notify bool
+	// Whether the local presence state has changed (so, other nodes must be notified)
+	share bool
+}
+
+// Presence keeps track of presence sets members per stream.
+// Members could join locally (via sessions) or be reported by other nodes.
+type Presence struct {
+	ttl time.Duration
+
+	// stream -> member ID -> member
+	streams map[string]map[string]*presenceMember
+	// sid -> stream -> member ID
+	sessions map[string]map[string]string
+
+	mu sync.Mutex
+}
+
+// NewPresence builds a new Presence struct
+func NewPresence(ttl time.Duration) *Presence {
+	return &Presence{
+		ttl:      ttl,
+		streams:  make(map[string]map[string]*presenceMember),
+		sessions: make(map[string]map[string]string),
+	}
+}
+
+// Join adds the session to the stream presence set.
+// A session could join a stream only once, so joining with another ID replaces the previous one.
+func (p *Presence) Join(sid string, stream string, id string, info interface{}) []*presenceUpdate {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	updates := []*presenceUpdate{}
+
+	if prev, ok := p.sessions[sid][stream]; ok {
+		if prev == id {
+			p.streams[stream][id].info = info
+			return updates
+		}
+
+		updates = append(updates, p.leave(sid, stream)...)
+	}
+
+	member := p.fetchMember(stream, id)
+
+	wasPresent := !member.isEmpty()
+	wasLocal := len(member.sessions) > 0
+
+	member.info = info
+	member.sessions[sid] = true
+
+	if _, ok := p.sessions[sid]; !ok {
+		p.sessions[sid] = make(map[string]string)
+	}
+
+	p.sessions[sid][stream] = id
+
+	if !wasPresent || !wasLocal {
+		updates = append(updates, &presenceUpdate{
+			stream: stream,
+			sid:    sid,
+			event:  &common.PresenceEvent{Type: common.PresenceJoinType, ID: id, Info: info},
+			notify: !wasPresent,
+			share:  !wasLocal,
+		})
+	}
+
+	return updates
+}
+
+// Leave removes the session from the stream presence set
+func (p *Presence) Leave(sid string, stream string) []*presenceUpdate {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.leave(sid, stream)
+}
+
+// RemoveSession removes the session from all presence sets
+func (p *Presence) RemoveSession(sid string) []*presenceUpdate {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	updates := []*presenceUpdate{}
+
+	for stream := range p.sessions[sid] {
+		updates = append(updates, p.leave(sid, stream)...)
+	}
+
+	return updates
+}
+
+// HandleRemote applies presence changes reported by another node
+func (p *Presence) HandleRemote(msg *common.RemotePresenceMessage) []*presenceUpdate {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	updates := []*presenceUpdate{}
+
+	switch msg.Event {
+	case common.PresenceJoinType, common.PresenceSyncType:
+		deadline := time.Now().Add(p.ttl)
+
+		for _, record := range msg.Records {
+			member := p.fetchMember(record.Stream, record.ID)
+			wasPresent := !member.isEmpty()
+
+			member.nodes[msg.Node] = deadline
+
+			if len(member.sessions) == 0 {
+				member.info = record.Info
+			}
+
+			if !wasPresent {
+				updates = append(updates, &presenceUpdate{
+					stream: record.Stream,
+					event:  &common.PresenceEvent{Type: common.PresenceJoinType, ID: record.ID, Info: record.Info},
+					notify: true,
+				})
+			}
+		}
+	case common.PresenceLeaveType:
+		for _, record := range msg.Records {
+			member, ok := p.streams[record.Stream][record.ID]
+
+			if !ok {
+				continue
+			}
+
+			delete(member.nodes, msg.Node)
+
+			if member.isEmpty() {
+				p.deleteMember(record.Stream, record.ID)
+
+				updates = append(updates, &presenceUpdate{
+					stream: record.Stream,
+					event:  &common.PresenceEvent{Type: common.PresenceLeaveType, ID: record.ID},
+					notify: true,
+				})
+			}
+		}
+	}
+
+	return updates
+}
+
+// Expire removes members which haven't been refreshed by other nodes in time
+// (e.g., when a node crashed)
+func (p *Presence) Expire() []*presenceUpdate {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	updates := []*presenceUpdate{}
+
+	for stream, members := range p.streams {
+		for id, member := range members {
+			for node, deadline := range member.nodes {
+				if now.After(deadline) {
+					delete(member.nodes, node)
+				}
+			}
+
+			if member.isEmpty() {
+				p.deleteMember(stream, id)
+
+				updates = append(updates, &presenceUpdate{
+					stream: stream,
+					event:  &common.PresenceEvent{Type: common.PresenceLeaveType, ID: id},
+					notify: true,
+				})
+			}
+		}
+	}
+
+	return updates
+}
+
+// Members returns the stream presence set members sorted by ID
+func (p *Presence) Members(stream string) []*common.PresenceRecord {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	records := []*common.PresenceRecord{}
+
+	for id, member := range p.streams[stream] {
+		records = append(records, &common.PresenceRecord{Stream: stream, ID: id, Info: member.info})
+	}
+
+	sort.Slice(records, func(i, j int) bool { return records[i].ID < records[j].ID })
+
+	return records
+}
+
+// Local returns all the members joined via the local sessions
+func (p *Presence) Local() []*common.PresenceRecord {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	records := []*common.PresenceRecord{}
+
+	for stream, members := range p.streams {
+		for id, member := range members {
+			if len(member.sessions) > 0 {
+				records = append(records, &common.PresenceRecord{Stream: stream, ID: id, Info: member.info})
+			}
+		}
+	}
+
+	return records
+}
+
+// Size returns the number of streams with non-empty presence sets
+func (p *Presence) Size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return len(p.streams)
+}
+
+func (p *Presence) leave(sid string, stream string) []*presenceUpdate {
+	id, ok := p.sessions[sid][stream]
+
+	if !ok {
+		return []*presenceUpdate{}
+	}
+
+	delete(p.sessions[sid], stream)
+
+	if len(p.sessions[sid]) == 0 {
+		delete(p.sessions, sid)
+	}
+
+	member := p.streams[stream][id]
+	delete(member.sessions, sid)
+
+	isLocal := len(member.sessions) > 0
+	isPresent := !member.isEmpty()
+
+	if !isPresent {
+		p.deleteMember(stream, id)
+	}
+
+	if isLocal {
+		return []*presenceUpdate{}
+	}
+
+	return []*presenceUpdate{
+		{
+			stream: stream,
+			sid:    sid,
+			event:  &common.PresenceEvent{Type: common.PresenceLeaveType, ID: id},
+			notify: !isPresent,
+			share:  true,
+		},
+	}
+}
+
+func (p *Presence) fetchMember(stream string, id string) *presenceMember {
+	if _, ok := p.streams[stream]; !ok {
+		p.streams[stream] = make(map[string]*presenceMember)
+	}
+
+	member, ok := p.streams[stream][id]
+
+	if !ok {
+		member = &presenceMember{sessions: make(map[string]bool), nodes: make(map[string]time.Time)}
+		p.streams[stream][id] = member
+	}
+
+	return member
+}
+
+func (p *Presence) deleteMember(stream string, id string) {
+	delete(p.streams[stream], id)
+
+	if len(p.streams[stream]) == 0 {
+		delete(p.streams, stream)
+	}
+}
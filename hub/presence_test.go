@@ -0,0 +1,137 @@
+package hub
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anycable/anycable-go/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPresenceJoin(t *testing.T) {
+	presence := NewPresence(time.Minute)
+
+	updates := presence.Join("s1", "chat", "42", "Jack")
+
+	require.Len(t, updates, 1)
+	assert.Equal(t, common.PresenceJoinType, updates[0].event.Type)
+	assert.True(t, updates[0].notify)
+	assert.True(t, updates[0].share)
+
+	t.Run("Joining with the same ID from another session", func(t *testing.T) {
+		updates := presence.Join("s2", "chat", "42", "Jack")
+		assert.Len(t, updates, 0)
+	})
+
+	t.Run("Joining with another ID replaces the previous one", func(t *testing.T) {
+		presence.Join("s3", "chat", "1", "Mike")
+		updates := presence.Join("s3", "chat", "2", "Mike")
+
+		require.Len(t, updates, 2)
+		assert.Equal(t, common.PresenceLeaveType, updates[0].event.Type)
+		assert.Equal(t, "1", updates[0].event.ID)
+		assert.Equal(t, common.PresenceJoinType, updates[1].event.Type)
+		assert.Equal(t, "2", updates[1].event.ID)
+	})
+
+	members := presence.Members("chat")
+
+	require.Len(t, members, 2)
+	assert.Equal(t, "2", members[0].ID)
+	assert.Equal(t, "42", members[1].ID)
+	assert.Equal(t, "Jack", members[1].Info)
+}
+
+func TestPresenceLeave(t *testing.T) {
+	presence := NewPresence(time.Minute)
+
+	presence.Join("s1", "chat", "42", nil)
+	presence.Join("s2", "chat", "42", nil)
+
+	assert.Len(t, presence.Leave("s1", "chat"), 0)
+
+	updates := presence.Leave("s2", "chat")
+
+	require.Len(t, updates, 1)
+	assert.Equal(t, common.PresenceLeaveType, updates[0].event.Type)
+	assert.Equal(t, "s2", updates[0].sid)
+
+	assert.Len(t, presence.Leave("s2", "chat"), 0)
+	assert.Equal(t, 0, presence.Size())
+}
+
+func TestPresenceRemoveSession(t *testing.T) {
+	presence := NewPresence(time.Minute)
+
+	presence.Join("s1", "chat", "42", nil)
+	presence.Join("s1", "room", "42", nil)
+
+	updates := presence.RemoveSession("s1")
+
+	assert.Len(t, updates, 2)
+	assert.Equal(t, 0, presence.Size())
+	assert.Len(t, presence.Local(), 0)
+}
+
+func TestPresenceRemote(t *testing.T) {
+	presence := NewPresence(time.Minute)
+
+	records := []*common.PresenceRecord{{Stream: "chat", ID: "42", Info: "Jack"}}
+
+	updates := presence.HandleRemote(&common.RemotePresenceMessage{Node: "n1", Event: common.PresenceJoinType, Records: records})
+
+	require.Len(t, updates, 1)
+	assert.True(t, updates[0].notify)
+	assert.False(t, updates[0].share)
+
+	t.Run("Local join of the remote member", func(t *testing.T) {
+		updates := presence.Join("s1", "chat", "42", "Jack")
+
+		require.Len(t, updates, 1)
+		assert.False(t, updates[0].notify)
+		assert.True(t, updates[0].share)
+
+		updates = presence.Leave("s1", "chat")
+
+		require.Len(t, updates, 1)
+		assert.False(t, updates[0].notify)
+		assert.True(t, updates[0].share)
+	})
+
+	t.Run("Sync refreshes members", func(t *testing.T) {
+		updates := presence.HandleRemote(&common.RemotePresenceMessage{Node: "n1", Event: common.PresenceSyncType, Records: records})
+		assert.Len(t, updates, 0)
+	})
+
+	updates = presence.HandleRemote(&common.RemotePresenceMessage{Node: "n1", Event: common.PresenceLeaveType, Records: records})
+
+	require.Len(t, updates, 1)
+	assert.Equal(t, common.PresenceLeaveType, updates[0].event.Type)
+	assert.Len(t, presence.Members("chat"), 0)
+}
+
+func TestPresenceExpire(t *testing.T) {
+	presence := NewPresence(10 * time.Millisecond)
+
+	presence.Join("s1", "chat", "1", nil)
+	presence.HandleRemote(&common.RemotePresenceMessage{
+		Node:    "n1",
+		Event:   common.PresenceJoinType,
+		Records: []*common.PresenceRecord{{Stream: "chat", ID: "2"}},
+	})
+
+	assert.Len(t, presence.Expire(), 0)
+
+	time.Sleep(20 * time.Millisecond)
+
+	updates := presence.Expire()
+
+	require.Len(t, updates, 1)
+	assert.Equal(t, "2", updates[0].event.ID)
+
+	members := presence.Members("chat")
+
+	require.Len(t, members, 1)
+	assert.Equal(t, "1", members[0].ID)
+}
@@ -0,0 +1,323 @@
+package hub
+
+import (
+	"sync"
+
+	"github.com/anycable/anycable-go/common"
+)
+
+// hubShard contains a part of the hub state: sessions (sharded by session ID),
+// identifiers (sharded by identifiers) and streams (sharded by stream name).
+// Every shard has its own locks, events channels and goroutine.
+type hubShard struct {
+	// Registered sessions
+	sessions map[string]HubSession
+
+	// Identifiers to session
+	identifiers map[string]map[string]bool
+
+	// Identifiers attributes ("name=value") to sessions
+	attributes map[string]map[string]bool
+
+	// Maps streams to sessions with identifiers
+	// stream -> sid -> identifier -> true
+	streams map[string]map[string]map[string]bool
+
+	// Maps sessions to identifiers to streams (only streams belonging to this shard)
+	// sid -> identifier -> [stream]
+	sessionsStreams map[string]map[string][]string
+
+	// Messages for specified stream
+	broadcast chan *common.StreamMessage
+
+	// Register requests from the sessions
+	register chan HubRegistration
+
+	// Subscribe requests to streams
+	subscribe chan HubSubscription
+
+	// Called when a stream gets the first subscriber or loses the last one (could be nil)
+	notifyStream StreamsNotifier
+
+	// mutex for streams mappings
+	streamsMu sync.RWMutex
+
+	// mutex for sessions tracking
+	sessionsMu sync.RWMutex
+}
+
+func newHubShard() *hubShard {
+	return &hubShard{
+		broadcast:       make(chan *common.StreamMessage, 256),
+		register:        make(chan HubRegistration, 2048),
+		subscribe:       make(chan HubSubscription, 128),
+		sessions:        make(map[string]HubSession),
+		identifiers:     make(map[string]map[string]bool),
+		attributes:      make(map[string]map[string]bool),
+		streams:         make(map[string]map[string]map[string]bool),
+		sessionsStreams: make(map[string]map[string][]string),
+	}
+}
+
+func (s *hubShard) addSession(sid string, session HubSession) {
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+
+	s.sessions[sid] = session
+}
+
+func (s *hubShard) removeSession(sid string) {
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+
+	delete(s.sessions, sid)
+}
+
+func (s *hubShard) session(sid string) (HubSession, bool) {
+	s.sessionsMu.RLock()
+	defer s.sessionsMu.RUnlock()
+
+	session, ok := s.sessions[sid]
+	return session, ok
+}
+
+func (s *hubShard) addIdentifiers(identifiers string, sid string) {
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+
+	if _, ok := s.identifiers[identifiers]; !ok {
+		s.identifiers[identifiers] = make(map[string]bool)
+	}
+
+	s.identifiers[identifiers][sid] = true
+}
+
+func (s *hubShard) removeIdentifiers(identifiers string, sid string) {
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+
+	delete(s.identifiers[identifiers], sid)
+
+	if len(s.identifiers[identifiers]) == 0 {
+		delete(s.identifiers, identifiers)
+	}
+}
+
+// sessionsFor returns a copy of session IDs for the specified identifiers
+func (s *hubShard) sessionsFor(identifiers string) ([]string, bool) {
+	s.sessionsMu.RLock()
+	defer s.sessionsMu.RUnlock()
+
+	ids, ok := s.identifiers[identifiers]
+
+	if !ok {
+		return nil, false
+	}
+
+	sids := make([]string, 0, len(ids))
+
+	for sid := range ids {
+		sids = append(sids, sid)
+	}
+
+	return sids, true
+}
+
+func (s *hubShard) addAttribute(attribute string, sid string) {
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+
+	if _, ok := s.attributes[attribute]; !ok {
+		s.attributes[attribute] = make(map[string]bool)
+	}
+
+	s.attributes[attribute][sid] = true
+}
+
+func (s *hubShard) removeAttribute(attribute string, sid string) {
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+
+	delete(s.attributes[attribute], sid)
+
+	if len(s.attributes[attribute]) == 0 {
+		delete(s.attributes, attribute)
+	}
+}
+
+// sessionsForAttribute returns a copy of session IDs with the specified identifiers attribute
+func (s *hubShard) sessionsForAttribute(attribute string) []string {
+	s.sessionsMu.RLock()
+	defer s.sessionsMu.RUnlock()
+
+	sids := make([]string, 0, len(s.attributes[attribute]))
+
+	for sid := range s.attributes[attribute] {
+		sids = append(sids, sid)
+	}
+
+	return sids
+}
+
+func (s *hubShard) subscribeSession(sid string, stream string, identifier string) {
+	s.streamsMu.Lock()
+	defer s.streamsMu.Unlock()
+
+	if _, ok := s.streams[stream]; !ok {
+		s.streams[stream] = make(map[string]map[string]bool)
+		s.streamChanged(stream, true)
+	}
+
+	if _, ok := s.streams[stream][sid]; !ok {
+		s.streams[stream][sid] = make(map[string]bool)
+	}
+
+	s.streams[stream][sid][identifier] = true
+
+	if _, ok := s.sessionsStreams[sid]; !ok {
+		s.sessionsStreams[sid] = make(map[string][]string)
+	}
+
+	s.sessionsStreams[sid][identifier] = append(
+		s.sessionsStreams[sid][identifier],
+		stream,
+	)
+}
+
+// unsubscribeSession returns true if the session has been subscribed to the stream
+func (s *hubShard) unsubscribeSession(sid string, stream string, identifier string) bool {
+	s.streamsMu.RLock()
+	if _, ok := s.streams[stream]; !ok {
+		s.streamsMu.RUnlock()
+		return false
+	}
+
+	if _, ok := s.streams[stream][sid]; !ok {
+		s.streamsMu.RUnlock()
+		return false
+	}
+
+	if _, ok := s.streams[stream][sid][identifier]; !ok {
+		s.streamsMu.RUnlock()
+		return false
+	}
+
+	s.streamsMu.RUnlock()
+
+	s.streamsMu.Lock()
+	defer s.streamsMu.Unlock()
+
+	s.removeStreamSession(stream, sid, identifier)
+
+	return true
+}
+
+func (s *hubShard) unsubscribeSessionFromChannel(sid string, identifier string, locked bool) {
+	if !locked {
+		// Most shards have no streams for the session, so we check it without acquiring the write lock
+		if !s.hasSessionStreams(sid) {
+			return
+		}
+
+		s.streamsMu.Lock()
+		defer s.streamsMu.Unlock()
+	}
+
+	if _, ok := s.sessionsStreams[sid]; !ok {
+		return
+	}
+
+	for _, stream := range s.sessionsStreams[sid][identifier] {
+		s.removeStreamSession(stream, sid, identifier)
+	}
+
+	delete(s.sessionsStreams[sid], identifier)
+
+	if len(s.sessionsStreams[sid]) == 0 {
+		delete(s.sessionsStreams, sid)
+	}
+}
+
+func (s *hubShard) unsubscribeSessionFromAllChannels(sid string) {
+	if !s.hasSessionStreams(sid) {
+		return
+	}
+
+	s.streamsMu.Lock()
+	defer s.streamsMu.Unlock()
+
+	for channel := range s.sessionsStreams[sid] {
+		s.unsubscribeSessionFromChannel(sid, channel, true)
+	}
+
+	delete(s.sessionsStreams, sid)
+}
+
+// removeStreamSession removes the session channel from the stream subscribers
+// and removes the stream itself if there are no subscribers left (must be called under the streams lock)
+func (s *hubShard) removeStreamSession(stream string, sid string, identifier string) {
+	if _, ok := s.streams[stream]; !ok {
+		return
+	}
+
+	delete(s.streams[stream][sid], identifier)
+
+	if len(s.streams[stream][sid]) == 0 {
+		delete(s.streams[stream], sid)
+	}
+
+	if len(s.streams[stream]) == 0 {
+		delete(s.streams, stream)
+		s.streamChanged(stream, false)
+	}
+}
+
+func (s *hubShard) streamChanged(stream string, active bool) {
+	if s.notifyStream != nil {
+		s.notifyStream(stream, active)
+	}
+}
+
+func (s *hubShard) hasSessionStreams(sid string) bool {
+	s.streamsMu.RLock()
+	defer s.streamsMu.RUnlock()
+
+	_, ok := s.sessionsStreams[sid]
+	return ok
+}
+
+func (s *hubShard) hasStream(stream string) bool {
+	s.streamsMu.RLock()
+	defer s.streamsMu.RUnlock()
+
+	_, ok := s.streams[stream]
+	return ok
+}
+
+func (s *hubShard) streamSessions(stream string) map[string][]string {
+	s.streamsMu.RLock()
+	defer s.streamsMu.RUnlock()
+
+	return streamSessionsSnapshot(s.streams[stream])
+}
+
+func (s *hubShard) size() int {
+	s.sessionsMu.RLock()
+	defer s.sessionsMu.RUnlock()
+
+	return len(s.sessions)
+}
+
+func (s *hubShard) uniqSize() int {
+	s.sessionsMu.RLock()
+	defer s.sessionsMu.RUnlock()
+
+	return len(s.identifiers)
+}
+
+func (s *hubShard) streamsSize() int {
+	s.streamsMu.RLock()
+	defer s.streamsMu.RUnlock()
+
+	return len(s.streams)
+}
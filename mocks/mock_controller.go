@@ -109,6 +109,11 @@ func (c *MockController) Perform(sid string, env *common.SessionEnv, id string,
 		res.IState = map[string]string{"_c_": "performed"}
 	}
 
+	if data == "broadcast_to_others" {
+		res.Broadcasts = []*common.StreamMessage{{Stream: "all", Data: "\"hey\"", ToOthers: true}}
+		res.Transmissions = nil
+	}
+
 	return res, nil
 }
 
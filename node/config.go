@@ -8,11 +8,31 @@ type Config struct {
 	StatsRefreshInterval int
 	// The max size of the Go routines pool for hub
 	HubGopoolSize int
+	// The number of hub shards (each shard has its own locks and goroutine)
+	HubShards int
 	// How should ping message timestamp be formatted? ('s' => seconds, 'ms' => milli seconds, 'ns' => nano seconds)
 	PingTimestampPrecision string
+	// The max number of messages to keep in a stream history (0 disables history)
+	HistoryLimit int
+	// How long to keep messages in a stream history (seconds)
+	HistoryTTL int
+	// How long to keep disconnected sessions to restore them (seconds, 0 disables restoring)
+	SessionRestoreTTL int
+	// The max number of whispers per second per session (0 means no limit)
+	WhisperRateLimit int
+	// The size of the per-session outgoing messages queue
+	SendQueueSize int
+	// What to do when the session send queue is full (disconnect, drop_oldest, drop_newest or coalesce)
+	SendQueuePolicy string
+	// The period to gradually close sessions on shutdown (seconds, 0 means closing all sessions at once)
+	ShutdownDrainPeriod int
+	// The max reconnect delay suggested to clients on shutdown (milliseconds, 0 means no delay)
+	ShutdownReconnectDelay int
+	// Whether to notify clients when broadcasts delivery is degraded (e.g., pub/sub connection is lost) and restored
+	BroadcastDegradedNotice bool
 }
 
 // NewConfig builds a new config
 func NewConfig() Config {
-	return Config{PingInterval: 3, StatsRefreshInterval: 5, HubGopoolSize: 16, PingTimestampPrecision: "s"}
+	return Config{PingInterval: 3, StatsRefreshInterval: 5, HubGopoolSize: 16, HubShards: 16, PingTimestampPrecision: "s", HistoryTTL: 300, WhisperRateLimit: 10, SendQueueSize: 256, SendQueuePolicy: SendQueueDisconnect}
 }
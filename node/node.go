@@ -1,19 +1,35 @@
 package node
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math/rand"
+	"net/url"
 	"runtime"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/anycable/anycable-go/common"
 	"github.com/anycable/anycable-go/hub"
 	"github.com/anycable/anycable-go/metrics"
+	"github.com/anycable/anycable-go/utils"
 	"github.com/anycable/anycable-go/ws"
 	"github.com/apex/log"
+	nanoid "github.com/matoous/go-nanoid"
 )
 
+// Query parameter to pass a restore token
+const restoreTokenParam = "restore_token"
+
+// Channel state key which enables whispering for the channel (set by the RPC app on subscribe)
+const whisperStateKey = "$w"
+
+// How often to check the sessions cache for expired sessions
+const sessionsCacheExpireInterval = time.Second
+
 const (
 	metricsGoroutines      = "goroutines_num"
 	metricsMemSys          = "mem_sys_bytes"
@@ -21,15 +37,21 @@ const (
 	metricsUniqClientsNum  = "clients_uniq_num"
 	metricsStreamsNum      = "broadcast_streams_num"
 	metricsDisconnectQueue = "disconnect_queue_size"
+	metricsSessionsCache   = "sessions_cache_size"
+	metricsSendQueueMax    = "send_queue_max_size"
 
 	metricsFailedAuths           = "failed_auths_total"
 	metricsReceivedMsg           = "client_msg_total"
 	metricsFailedCommandReceived = "failed_client_msg_total"
 	metricsBroadcastMsg          = "broadcast_msg_total"
+	metricsNodeBroadcastMsg      = "node_broadcast_msg_total"
 	metricsUnknownBroadcast      = "failed_broadcast_msg_total"
+	metricsRestoredSessions      = "restored_sessions_total"
+	metricsRemoteDisconnects     = "remote_disconnects_total"
 
 	metricsSentMsg    = "server_msg_total"
 	metricsFailedSent = "failed_server_msg_total"
+	metricsDroppedMsg = "dropped_server_msg_total"
 
 	metricsDataSent     = "data_sent_total"
 	metricsDataReceived = "data_rcvd_total"
@@ -46,6 +68,11 @@ type AppNode interface {
 	Disconnect(s *Session) error
 }
 
+// Publisher is used to send messages to other nodes through the pub/sub layer
+type Publisher interface {
+	Publish(msg []byte) error
+}
+
 // Connection represents underlying connection
 type Connection interface {
 	Write(msg []byte, deadline time.Time) error
@@ -58,14 +85,24 @@ type Connection interface {
 type Node struct {
 	metrics metrics.Instrumenter
 
+	// Unique node ID (used to distinguish our own pub/sub messages)
+	id           string
 	config       *Config
 	hub          *hub.Hub
 	controller   Controller
 	disconnector Disconnector
-	shutdownCh   chan struct{}
-	shutdownMu   sync.Mutex
-	closed       bool
-	log          *log.Entry
+	publisher    Publisher
+	sessions     *SessionsCache
+	// The max send queue length observed since the last stats collection
+	sendQueueMark *highWaterMark
+	shutdownCh    chan struct{}
+	shutdownMu    sync.Mutex
+	closed        bool
+	// Set to 1 when the node is draining (i.e., not accepting new connections)
+	draining int32
+	// Set to 1 when clients have been notified about degraded broadcasts
+	broadcastDegraded int32
+	log               *log.Entry
 }
 
 var _ AppNode = (*Node)(nil)
@@ -73,14 +110,25 @@ var _ AppNode = (*Node)(nil)
 // NewNode builds new node struct
 func NewNode(controller Controller, metrics *metrics.Metrics, config *Config) *Node {
 	node := &Node{
-		metrics:    metrics,
-		config:     config,
-		controller: controller,
-		shutdownCh: make(chan struct{}),
-		log:        log.WithFields(log.Fields{"context": "node"}),
+		id:            newNodeID(),
+		metrics:       metrics,
+		config:        config,
+		controller:    controller,
+		sendQueueMark: &highWaterMark{},
+		shutdownCh:    make(chan struct{}),
+		log:           log.WithFields(log.Fields{"context": "node"}),
+	}
+
+	node.hub = hub.NewShardedHub(config.HubGopoolSize, config.HubShards)
+	node.hub.OnPresenceChange(node.publishPresence)
+
+	if config.HistoryLimit > 0 {
+		node.hub.EnableHistory(config.HistoryLimit, time.Duration(config.HistoryTTL)*time.Second)
 	}
 
-	node.hub = hub.NewHub(config.HubGopoolSize)
+	if config.SessionRestoreTTL > 0 {
+		node.sessions = NewSessionsCache(time.Duration(config.SessionRestoreTTL) * time.Second)
+	}
 
 	if metrics != nil {
 		node.registerMetrics()
@@ -93,6 +141,11 @@ func NewNode(controller Controller, metrics *metrics.Metrics, config *Config) *N
 func (n *Node) Start() error {
 	go n.hub.Run()
 	go n.collectStats()
+	go n.syncPresence()
+
+	if n.sessions != nil {
+		go n.expireSessions()
+	}
 
 	return nil
 }
@@ -102,6 +155,18 @@ func (n *Node) SetDisconnector(d Disconnector) {
 	n.disconnector = d
 }
 
+// OnStreamsChange sets the callback to track streams with local subscribers
+// (e.g., to subscribe to stream-specific pub/sub channels).
+// Must be called before Start.
+func (n *Node) OnStreamsChange(fn hub.StreamsNotifier) {
+	n.hub.OnStreamsChange(fn)
+}
+
+// SetPublisher sets publisher to share broadcasts and the node state (e.g., presence) with other nodes
+func (n *Node) SetPublisher(p Publisher) {
+	n.publisher = p
+}
+
 // HandleCommand parses incoming message from client and
 // execute the command (if recognized)
 func (n *Node) HandleCommand(s *Session, msg *common.Message) (err error) {
@@ -113,6 +178,16 @@ func (n *Node) HandleCommand(s *Session, msg *common.Message) (err error) {
 		_, err = n.Unsubscribe(s, msg)
 	case "message":
 		_, err = n.Perform(s, msg)
+	case "history":
+		err = n.History(s, msg)
+	case "join":
+		err = n.PresenceJoin(s, msg)
+	case "leave":
+		err = n.PresenceLeave(s, msg)
+	case "presence":
+		err = n.Presence(s, msg)
+	case "whisper":
+		err = n.Whisper(s, msg)
 	default:
 		err = fmt.Errorf("Unknown command: %s", msg.Command)
 	}
@@ -132,9 +207,23 @@ func (n *Node) HandlePubSub(raw []byte) {
 
 	switch v := msg.(type) {
 	case common.StreamMessage:
-		n.Broadcast(&v)
+		// Our own broadcasts have been already delivered locally
+		if v.Node != n.id {
+			n.Broadcast(&v)
+		}
 	case common.RemoteDisconnectMessage:
 		n.RemoteDisconnect(&v)
+	case common.RemoteStreamStartMessage:
+		n.RemoteStreamStart(&v)
+	case common.RemoteStreamStopMessage:
+		n.RemoteStreamStop(&v)
+	case common.RemotePresenceMessage:
+		// We receive our own presence messages, too
+		if v.Node != n.id {
+			n.hub.HandleRemotePresence(&v)
+		}
+	case common.RemoteDisconnectAckMessage:
+		// Acks are meant for the backend, nothing to do
 	}
 }
 
@@ -144,6 +233,37 @@ func (n *Node) LookupSession(id string) *Session {
 	return session
 }
 
+// Streams returns the information about the active streams
+func (n *Node) Streams() []*hub.StreamInfo {
+	return n.hub.Streams()
+}
+
+// SessionIDs returns the IDs of all the active sessions
+func (n *Node) SessionIDs() []string {
+	return n.hub.SessionIDs()
+}
+
+// SessionInfo returns the details of the session with the specified ID (or nil)
+func (n *Node) SessionInfo(sid string) *SessionInfo {
+	if session, ok := n.hub.FindBySessionID(sid).(*Session); ok {
+		return session.Info()
+	}
+
+	return nil
+}
+
+// SessionsInfoByIdentifiers returns the details of all the sessions with the specified identifiers
+func (n *Node) SessionsInfoByIdentifiers(identifiers string) []*SessionInfo {
+	sessions := n.sessionsByIdentifiers(identifiers)
+	res := make([]*SessionInfo, 0, len(sessions))
+
+	for _, session := range sessions {
+		res = append(res, session.Info())
+	}
+
+	return res
+}
+
 // Shutdown stops all services (hub, controller)
 func (n *Node) Shutdown() (err error) {
 	n.shutdownMu.Lock()
@@ -158,15 +278,19 @@ func (n *Node) Shutdown() (err error) {
 	n.shutdownMu.Unlock()
 
 	if n.hub != nil {
+		// Let other nodes know that our presence sets members are gone
+		if records := n.hub.LocalPresence(); len(records) > 0 {
+			n.publishRemotePresence(&common.RemotePresenceMessage{Node: n.id, Event: common.PresenceLeaveType, Records: records})
+		}
+
 		n.hub.Shutdown()
 
 		active := n.hub.Size()
 
 		if active > 0 {
 			n.log.Infof("Closing active connections: %d", active)
-			disconnectMessage := common.NewDisconnectMessage(common.SERVER_RESTART_REASON, true)
 
-			n.hub.DisconnectSesssions(disconnectMessage, common.SERVER_RESTART_REASON)
+			n.hub.DisconnectSesssions(n.shutdownDisconnectMessage(), common.SERVER_RESTART_REASON)
 
 			n.log.Info("All active connections closed")
 
@@ -175,6 +299,11 @@ func (n *Node) Shutdown() (err error) {
 		}
 	}
 
+	// Sessions couldn't be restored after restart, so we must notify the app about disconnects
+	if n.sessions != nil {
+		n.enqueueDisconnects(n.sessions.Flush())
+	}
+
 	if n.disconnector != nil {
 		err := n.disconnector.Shutdown()
 
@@ -194,9 +323,132 @@ func (n *Node) Shutdown() (err error) {
 	return
 }
 
+// Drain marks the node as draining (so, new connections must be rejected) and gradually
+// closes active sessions during the drain period (in random order).
+// Does nothing if the drain period is not configured.
+func (n *Node) Drain() error {
+	if n.config.ShutdownDrainPeriod <= 0 {
+		return nil
+	}
+
+	if !atomic.CompareAndSwapInt32(&n.draining, 0, 1) {
+		return errors.New("Already draining")
+	}
+
+	period := time.Duration(n.config.ShutdownDrainPeriod) * time.Second
+	sids := n.hub.SessionIDs()
+
+	n.log.Infof("Draining active connections: %d (period: %v)", len(sids), period)
+
+	// Every session is closed at a random moment within the drain period
+	offsets := make([]time.Duration, len(sids))
+
+	for i := range offsets {
+		offsets[i] = time.Duration(rand.Int63n(int64(period))) // #nosec
+	}
+
+	sort.Sort(byDuration(offsets))
+	rand.Shuffle(len(sids), func(i, j int) { sids[i], sids[j] = sids[j], sids[i] })
+
+	start := time.Now()
+
+	for i, sid := range sids {
+		if wait := time.Until(start.Add(offsets[i])); wait > 0 {
+			select {
+			case <-n.shutdownCh:
+				return nil
+			case <-time.After(wait):
+			}
+		}
+
+		if session := n.hub.FindBySessionID(sid); session != nil {
+			session.DisconnectWithMessage(n.shutdownDisconnectMessage(), common.SERVER_RESTART_REASON)
+		}
+	}
+
+	n.log.Info("All active connections drained")
+
+	return nil
+}
+
+// Draining returns true if the node is draining connections and mustn't accept new ones
+func (n *Node) Draining() bool {
+	return atomic.LoadInt32(&n.draining) == 1
+}
+
+// shutdownDisconnectMessage returns a disconnect message with a random reconnect delay
+// (so clients don't reconnect all at once)
+func (n *Node) shutdownDisconnectMessage() *common.DisconnectMessage {
+	msg := common.NewDisconnectMessage(common.SERVER_RESTART_REASON, true)
+
+	if n.config.ShutdownReconnectDelay > 0 {
+		msg.ReconnectDelay = rand.Intn(n.config.ShutdownReconnectDelay + 1) // #nosec
+	}
+
+	return msg
+}
+
+// NotifyBroadcastState notifies all sessions when broadcasts delivery is degraded
+// (e.g., pub/sub connection is lost) and when it's restored (if enabled)
+func (n *Node) NotifyBroadcastState(ready bool) {
+	if !n.config.BroadcastDegradedNotice {
+		return
+	}
+
+	msg := &common.Reply{Type: common.BroadcastRestoredType}
+
+	if ready {
+		// Only notify about restoring if clients have been notified about degradation
+		if !atomic.CompareAndSwapInt32(&n.broadcastDegraded, 1, 0) {
+			return
+		}
+	} else {
+		if !atomic.CompareAndSwapInt32(&n.broadcastDegraded, 0, 1) {
+			return
+		}
+
+		msg.Type = common.BroadcastDegradedType
+	}
+
+	sids := n.hub.SessionIDs()
+
+	n.log.Infof("Notifying sessions about broadcasts state (%s): %d", msg.Type, len(sids))
+
+	for _, sid := range sids {
+		if session := n.hub.FindBySessionID(sid); session != nil {
+			session.Send(msg)
+		}
+	}
+}
+
+type byDuration []time.Duration
+
+func (d byDuration) Len() int           { return len(d) }
+func (d byDuration) Less(i, j int) bool { return d[i] < d[j] }
+func (d byDuration) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
+
 // Authenticate calls controller to perform authentication.
 // If authentication is successful, session is registered with a hub.
+// If the session could be restored (using a restore token), no controller calls are made.
+// Sessions are not restored while the node is draining.
 func (n *Node) Authenticate(s *Session) (res *common.ConnectResult, err error) {
+	if n.sessions != nil {
+		prev, expired := n.sessions.Fetch(restoreTokenFromURL(s.env.URL))
+
+		if expired != nil {
+			n.enqueueDisconnects([]*Session{expired})
+		}
+
+		if prev != nil {
+			if !n.Draining() {
+				return n.restoreSession(s, prev), nil
+			}
+
+			s.Log.Debugf("Skip restoring session while draining: %s", prev.GetID())
+			n.enqueueDisconnects([]*Session{prev})
+		}
+	}
+
 	res, err = n.controller.Authenticate(s.GetID(), s.env)
 
 	if err != nil {
@@ -209,6 +461,10 @@ func (n *Node) Authenticate(s *Session) (res *common.ConnectResult, err error) {
 		s.Connected = true
 
 		n.hub.AddSession(s)
+
+		if n.sessions != nil {
+			res.Transmissions = n.assignRestoreToken(s, res.Transmissions)
+		}
 	} else {
 		if res.Status == common.FAILURE {
 			n.metrics.CounterIncrement(metricsFailedAuths)
@@ -222,6 +478,89 @@ func (n *Node) Authenticate(s *Session) (res *common.ConnectResult, err error) {
 	return
 }
 
+// restoreSession transfers identifiers, state and subscriptions from the previous session
+// to the new one and registers it within the hub
+func (n *Node) restoreSession(s *Session, prev *Session) *common.ConnectResult {
+	s.Log.Debugf("Restoring session: %s", prev.GetID())
+
+	prev.smu.Lock()
+	s.smu.Lock()
+	s.SetIdentifiers(prev.GetIdentifiers())
+	s.env.ConnectionState = prev.env.ConnectionState
+	s.env.ChannelStates = prev.env.ChannelStates
+	s.subscriptions = prev.subscriptions
+	s.smu.Unlock()
+	prev.smu.Unlock()
+
+	s.Connected = true
+
+	n.hub.AddSession(s)
+
+	uid := s.GetID()
+
+	for channel, streams := range s.subscriptions.ToMap() {
+		for _, stream := range streams {
+			n.hub.SubscribeSession(uid, stream, channel)
+		}
+	}
+
+	n.metrics.CounterIncrement(metricsRestoredSessions)
+
+	reply := &common.Reply{Type: common.SessionRestoredType}
+
+	if token, err := newRestoreToken(); err == nil {
+		s.restoreToken = token
+		reply.RestoreToken = token
+	}
+
+	s.Send(reply)
+
+	return &common.ConnectResult{Identifier: s.GetIdentifiers(), Status: common.SUCCESS}
+}
+
+// assignRestoreToken generates a restore token for the session and adds it to the welcome message
+func (n *Node) assignRestoreToken(s *Session, transmissions []string) []string {
+	token, err := newRestoreToken()
+
+	if err != nil {
+		s.Log.Warnf("Failed to generate restore token: %v", err)
+		return transmissions
+	}
+
+	for i, transmission := range transmissions {
+		var msg map[string]interface{}
+
+		if err := json.Unmarshal([]byte(transmission), &msg); err != nil {
+			continue
+		}
+
+		if msg["type"] != common.WelcomeType {
+			continue
+		}
+
+		msg[restoreTokenParam] = token
+
+		if b, err := json.Marshal(msg); err == nil {
+			transmissions[i] = string(b)
+			s.restoreToken = token
+		}
+
+		break
+	}
+
+	return transmissions
+}
+
+func restoreTokenFromURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+
+	if err != nil {
+		return ""
+	}
+
+	return u.Query().Get(restoreTokenParam)
+}
+
 // Subscribe subscribes session to a channel
 func (n *Node) Subscribe(s *Session, msg *common.Message) (res *common.CommandResult, err error) {
 	s.smu.Lock()
@@ -272,6 +611,10 @@ func (n *Node) Unsubscribe(s *Session, msg *common.Message) (res *common.Command
 		// Make sure to remove all streams subscriptions
 		res.StopAllStreams = true
 
+		for _, stream := range s.subscriptions.StreamsFor(msg.Identifier) {
+			n.hub.PresenceLeave(s.GetID(), stream)
+		}
+
 		s.subscriptions.RemoveChannel(msg.Identifier)
 
 		s.Log.Debugf("Unsubscribed from channel: %s", msg.Identifier)
@@ -322,6 +665,179 @@ func (n *Node) Perform(s *Session, msg *common.Message) (res *common.CommandResu
 	return
 }
 
+// History sends the messages missed by the client for the channel streams.
+// The client receives either the missed messages followed by the "confirm_history" message
+// or the "reject_history" message with the failure reason.
+func (n *Node) History(s *Session, msg *common.Message) (err error) {
+	if ok := s.subscriptions.HasChannel(msg.Identifier); !ok {
+		err = fmt.Errorf("Unknown subscription %s", msg.Identifier)
+		return
+	}
+
+	if msg.History == nil {
+		err = errors.New("History request is missing")
+		return
+	}
+
+	backlog, err := n.retrieveHistory(s.subscriptions.StreamsFor(msg.Identifier), msg.History)
+
+	if err != nil {
+		s.Send(&common.Reply{Type: common.HistoryRejectedType, Identifier: msg.Identifier, Reason: err.Error()})
+		return
+	}
+
+	for _, message := range backlog {
+		if message.ExcludeSocket == s.GetID() {
+			continue
+		}
+
+		s.Send(message.ToReplyFor(msg.Identifier))
+	}
+
+	s.Send(&common.Reply{Type: common.HistoryConfirmedType, Identifier: msg.Identifier})
+
+	return
+}
+
+func (n *Node) retrieveHistory(streams []string, request *common.HistoryRequest) ([]*common.StreamMessage, error) {
+	backlog := []*common.StreamMessage{}
+
+	for _, stream := range streams {
+		var messages []*common.StreamMessage
+		var err error
+
+		if pos, ok := request.Streams[stream]; ok {
+			messages, err = n.hub.HistoryFrom(stream, pos.Epoch, pos.Offset)
+		} else if request.Since > 0 {
+			messages, err = n.hub.HistorySince(stream, request.Since)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("Couldn't retrieve history for %s: %v", stream, err)
+		}
+
+		backlog = append(backlog, messages...)
+	}
+
+	return backlog, nil
+}
+
+// PresenceJoin adds the session to the presence sets of the channel streams
+func (n *Node) PresenceJoin(s *Session, msg *common.Message) (err error) {
+	if ok := s.subscriptions.HasChannel(msg.Identifier); !ok {
+		err = fmt.Errorf("Unknown subscription %s", msg.Identifier)
+		return
+	}
+
+	if msg.Presence == nil || msg.Presence.ID == "" {
+		err = errors.New("Presence ID is missing")
+		return
+	}
+
+	for _, stream := range s.subscriptions.StreamsFor(msg.Identifier) {
+		n.hub.PresenceJoin(s.GetID(), stream, msg.Presence.ID, msg.Presence.Info)
+	}
+
+	return
+}
+
+// PresenceLeave removes the session from the presence sets of the channel streams
+func (n *Node) PresenceLeave(s *Session, msg *common.Message) (err error) {
+	if ok := s.subscriptions.HasChannel(msg.Identifier); !ok {
+		err = fmt.Errorf("Unknown subscription %s", msg.Identifier)
+		return
+	}
+
+	for _, stream := range s.subscriptions.StreamsFor(msg.Identifier) {
+		n.hub.PresenceLeave(s.GetID(), stream)
+	}
+
+	return
+}
+
+// Presence sends the current members of the channel streams presence sets to the session
+func (n *Node) Presence(s *Session, msg *common.Message) (err error) {
+	if ok := s.subscriptions.HasChannel(msg.Identifier); !ok {
+		err = fmt.Errorf("Unknown subscription %s", msg.Identifier)
+		return
+	}
+
+	records := []*common.PresenceRecord{}
+	seen := make(map[string]bool)
+
+	for _, stream := range s.subscriptions.StreamsFor(msg.Identifier) {
+		for _, record := range n.hub.PresenceMembers(stream) {
+			if seen[record.ID] {
+				continue
+			}
+
+			seen[record.ID] = true
+			records = append(records, &common.PresenceRecord{ID: record.ID, Info: record.Info})
+		}
+	}
+
+	s.Send(&common.Reply{
+		Type:       common.PresenceType,
+		Identifier: msg.Identifier,
+		Message:    &common.PresenceInfo{Type: common.PresenceInfoType, Total: len(records), Records: records},
+	})
+
+	return
+}
+
+// Whisper broadcasts the client's message to the channel streams (excluding the sender) without calling RPC.
+// Whispering must be enabled for the channel via the channel state.
+func (n *Node) Whisper(s *Session, msg *common.Message) (err error) {
+	if ok := s.subscriptions.HasChannel(msg.Identifier); !ok {
+		err = fmt.Errorf("Unknown subscription %s", msg.Identifier)
+		return
+	}
+
+	s.smu.Lock()
+
+	allowed := s.env.GetChannelStateField(msg.Identifier, whisperStateKey) != ""
+
+	if allowed && n.config.WhisperRateLimit > 0 && s.whisperLimiter == nil {
+		s.whisperLimiter = utils.NewRateLimiter(n.config.WhisperRateLimit, n.config.WhisperRateLimit)
+	}
+
+	limiter := s.whisperLimiter
+
+	s.smu.Unlock()
+
+	if !allowed {
+		err = fmt.Errorf("Whispering is not allowed for %s", msg.Identifier)
+		return
+	}
+
+	if limiter != nil && !limiter.Allow() {
+		err = errors.New("Whispers rate limit exceeded")
+		return
+	}
+
+	var data string
+
+	switch v := msg.Data.(type) {
+	case string:
+		data = v
+	default:
+		b, jsonErr := json.Marshal(v)
+
+		if jsonErr != nil {
+			err = fmt.Errorf("Failed to encode whisper data: %v", jsonErr)
+			return
+		}
+
+		data = string(b)
+	}
+
+	for _, stream := range s.subscriptions.StreamsFor(msg.Identifier) {
+		n.publishBroadcast(&common.StreamMessage{Stream: stream, Data: data, ExcludeSocket: s.GetID(), Ephemeral: true})
+	}
+
+	return
+}
+
 // Broadcast message to stream
 func (n *Node) Broadcast(msg *common.StreamMessage) {
 	n.metrics.CounterIncrement(metricsBroadcastMsg)
@@ -329,12 +845,38 @@ func (n *Node) Broadcast(msg *common.StreamMessage) {
 	n.hub.BroadcastMessage(msg)
 }
 
-// Disconnect adds session to disconnector queue and unregister session from hub
+// Disconnect adds session to disconnector queue and unregister session from hub.
+// Restorable sessions are kept in the sessions cache and added to the queue only when expired.
 func (n *Node) Disconnect(s *Session) error {
 	n.hub.RemoveSessionLater(s)
+
+	if n.sessions != nil && s.restoreToken != "" {
+		n.sessions.Store(s)
+		return nil
+	}
+
 	return n.disconnector.Enqueue(s)
 }
 
+func (n *Node) expireSessions() {
+	for {
+		select {
+		case <-n.shutdownCh:
+			return
+		case <-time.After(sessionsCacheExpireInterval):
+			n.enqueueDisconnects(n.sessions.Expire())
+		}
+	}
+}
+
+func (n *Node) enqueueDisconnects(sessions []*Session) {
+	for _, s := range sessions {
+		if err := n.disconnector.Enqueue(s); err != nil {
+			s.Log.Warnf("Failed to enqueue disconnect: %v", err)
+		}
+	}
+}
+
 // DisconnectNow execute disconnect on controller
 func (n *Node) DisconnectNow(s *Session) error {
 	sessionSubscriptions := s.subscriptions.Channels()
@@ -357,11 +899,197 @@ func (n *Node) DisconnectNow(s *Session) error {
 	return err
 }
 
-// RemoteDisconnect find a session by identifier and closes it
+// RemoteDisconnect finds sessions matching the message selectors and closes them
 func (n *Node) RemoteDisconnect(msg *common.RemoteDisconnectMessage) {
 	n.metrics.CounterIncrement(metricsBroadcastMsg)
 	n.log.Debugf("Incoming pubsub command: %v", msg)
-	n.hub.RemoteDisconnect(msg)
+
+	count := n.hub.RemoteDisconnect(msg)
+
+	n.metrics.CounterAdd(metricsRemoteDisconnects, uint64(count))
+
+	if count > 0 {
+		n.log.Infof("Disconnecting %d sessions remotely", count)
+	}
+
+	if msg.AckID != "" {
+		if n.publisher == nil {
+			n.log.Warnf("Couldn't send disconnect acknowledgement %s: publishing is not configured (e.g., HTTP adapter without a broadcast bus)", msg.AckID)
+			return
+		}
+
+		n.publishCommand("disconnect_ack", &common.RemoteDisconnectAckMessage{AckID: msg.AckID, Node: n.id, Sessions: count})
+	}
+}
+
+// RemoteStreamStart subscribes the sessions with the specified identifiers to the stream
+// (only sessions subscribed to the channel are affected)
+func (n *Node) RemoteStreamStart(msg *common.RemoteStreamStartMessage) {
+	n.metrics.CounterIncrement(metricsBroadcastMsg)
+	n.log.Debugf("Incoming pubsub command: %v", msg)
+
+	for _, s := range n.sessionsByIdentifiers(msg.Identifier) {
+		s.smu.Lock()
+
+		if s.subscriptions.HasChannel(msg.Channel) {
+			n.hub.SubscribeSession(s.GetID(), msg.Stream, msg.Channel)
+			s.subscriptions.AddChannelStream(msg.Channel, msg.Stream)
+		}
+
+		s.smu.Unlock()
+	}
+}
+
+// RemoteStreamStop unsubscribes the sessions with the specified identifiers from the stream.
+// If the stream is not specified, the sessions are unsubscribed from the channel
+// (the channel's unsubscribe callback is invoked, and the client receives the "unsubscribed" message).
+func (n *Node) RemoteStreamStop(msg *common.RemoteStreamStopMessage) {
+	n.metrics.CounterIncrement(metricsBroadcastMsg)
+	n.log.Debugf("Incoming pubsub command: %v", msg)
+
+	for _, s := range n.sessionsByIdentifiers(msg.Identifier) {
+		uid := s.GetID()
+
+		s.smu.Lock()
+
+		if !s.subscriptions.HasChannel(msg.Channel) {
+			s.smu.Unlock()
+			continue
+		}
+
+		if msg.Stream != "" {
+			n.hub.UnsubscribeSession(uid, msg.Stream, msg.Channel)
+			n.hub.PresenceLeave(uid, msg.Stream)
+			s.subscriptions.RemoveChannelStream(msg.Channel, msg.Stream)
+			s.smu.Unlock()
+			continue
+		}
+
+		s.smu.Unlock()
+
+		n.remoteUnsubscribe(s, msg.Channel)
+	}
+}
+
+// remoteUnsubscribe unsubscribes the session from the channel the same way as the client "unsubscribe" command does
+// (i.e., the channel's unsubscribe callback is invoked). If the controller fails,
+// the subscription is still removed to make sure the access is revoked.
+func (n *Node) remoteUnsubscribe(s *Session, channel string) {
+	_, err := n.Unsubscribe(s, &common.Message{Command: "unsubscribe", Identifier: channel})
+
+	if err != nil {
+		s.smu.Lock()
+
+		if !s.subscriptions.HasChannel(channel) {
+			s.smu.Unlock()
+			return
+		}
+
+		s.Log.Warnf("Failed to unsubscribe from channel remotely, removing subscription anyway: %s (%v)", channel, err)
+
+		uid := s.GetID()
+
+		for _, stream := range s.subscriptions.StreamsFor(channel) {
+			n.hub.PresenceLeave(uid, stream)
+		}
+
+		n.hub.UnsubscribeSessionFromChannel(uid, channel)
+		s.subscriptions.RemoveChannel(channel)
+
+		s.smu.Unlock()
+	}
+
+	s.Log.Debugf("Unsubscribed from channel remotely: %s", channel)
+	s.Send(common.UnsubscribedMessage(channel))
+}
+
+func (n *Node) sessionsByIdentifiers(identifiers string) []*Session {
+	hubSessions := n.hub.FindAllByIdentifier(identifiers)
+	sessions := make([]*Session, 0, len(hubSessions))
+
+	for _, hubSession := range hubSessions {
+		if session, ok := hubSession.(*Session); ok {
+			sessions = append(sessions, session)
+		}
+	}
+
+	return sessions
+}
+
+func (n *Node) publishPresence(event string, record *common.PresenceRecord) {
+	n.publishRemotePresence(&common.RemotePresenceMessage{Node: n.id, Event: event, Records: []*common.PresenceRecord{record}})
+}
+
+// syncPresence periodically shares all local presence sets members with other nodes,
+// so they could expire members of the crashed nodes
+func (n *Node) syncPresence() {
+	for {
+		select {
+		case <-n.shutdownCh:
+			return
+		case <-time.After(hub.PresenceSyncInterval):
+			records := n.hub.LocalPresence()
+
+			if len(records) > 0 {
+				n.publishRemotePresence(&common.RemotePresenceMessage{Node: n.id, Event: common.PresenceSyncType, Records: records})
+			}
+		}
+	}
+}
+
+// publishBroadcast delivers the message to the local subscribers and publishes it to other nodes.
+// Published messages contain the node ID, so we skip them when they come back via pub/sub.
+func (n *Node) publishBroadcast(msg *common.StreamMessage) {
+	clusterMsg := *msg
+	clusterMsg.Node = n.id
+
+	n.metrics.CounterIncrement(metricsNodeBroadcastMsg)
+	n.log.Debugf("Node broadcast message: %v", msg)
+	n.hub.BroadcastMessage(msg)
+
+	if n.publisher == nil {
+		return
+	}
+
+	raw, err := json.Marshal(&clusterMsg)
+
+	if err != nil {
+		n.log.Errorf("Failed to encode broadcast message: %v", err)
+		return
+	}
+
+	if err := n.publisher.Publish(raw); err != nil {
+		n.log.Warnf("Failed to publish broadcast message: %v", err)
+	}
+}
+
+func (n *Node) publishRemotePresence(msg *common.RemotePresenceMessage) {
+	n.publishCommand("presence", msg)
+}
+
+// publishCommand sends the remote command to other nodes (and the backend)
+func (n *Node) publishCommand(command string, msg interface{}) {
+	if n.publisher == nil {
+		return
+	}
+
+	payload, err := json.Marshal(msg)
+
+	if err != nil {
+		n.log.Errorf("Failed to encode %s message: %v", command, err)
+		return
+	}
+
+	raw, err := json.Marshal(&common.RemoteCommandMessage{Command: command, Payload: payload})
+
+	if err != nil {
+		n.log.Errorf("Failed to encode %s message: %v", command, err)
+		return
+	}
+
+	if err := n.publisher.Publish(raw); err != nil {
+		n.log.Warnf("Failed to publish %s message: %v", command, err)
+	}
 }
 
 func transmit(s *Session, transmissions []string) {
@@ -426,7 +1154,11 @@ func (n *Node) handleCallReply(s *Session, reply *common.CallResult) bool {
 
 	if reply.Broadcasts != nil {
 		for _, broadcast := range reply.Broadcasts {
-			n.Broadcast(broadcast)
+			if broadcast.ToOthers {
+				broadcast.ExcludeSocket = s.GetID()
+			}
+
+			n.publishBroadcast(broadcast)
 		}
 	}
 
@@ -461,6 +1193,11 @@ func (n *Node) collectStatsOnce() {
 	n.metrics.GaugeSet(metricsUniqClientsNum, uint64(n.hub.UniqSize()))
 	n.metrics.GaugeSet(metricsStreamsNum, uint64(n.hub.StreamsSize()))
 	n.metrics.GaugeSet(metricsDisconnectQueue, uint64(n.disconnector.Size()))
+	n.metrics.GaugeSet(metricsSendQueueMax, uint64(n.sendQueueMark.Reset()))
+
+	if n.sessions != nil {
+		n.metrics.GaugeSet(metricsSessionsCache, uint64(n.sessions.Size()))
+	}
 }
 
 func (n *Node) registerMetrics() {
@@ -471,16 +1208,32 @@ func (n *Node) registerMetrics() {
 	n.metrics.RegisterGauge(metricsUniqClientsNum, "The number of unique clients (with respect to connection identifiers)")
 	n.metrics.RegisterGauge(metricsStreamsNum, "The number of active broadcasting streams")
 	n.metrics.RegisterGauge(metricsDisconnectQueue, "The size of delayed disconnect")
+	n.metrics.RegisterGauge(metricsSessionsCache, "The number of disconnected sessions kept for restoring")
+	n.metrics.RegisterGauge(metricsSendQueueMax, "The max size of the sessions send queues since the last stats collection")
 
 	n.metrics.RegisterCounter(metricsFailedAuths, "The total number of failed authentication attempts")
 	n.metrics.RegisterCounter(metricsReceivedMsg, "The total number of received messages from clients")
 	n.metrics.RegisterCounter(metricsFailedCommandReceived, "The total number of unrecognized messages received from clients")
 	n.metrics.RegisterCounter(metricsBroadcastMsg, "The total number of messages received through PubSub (for broadcast)")
+	n.metrics.RegisterCounter(metricsNodeBroadcastMsg, "The total number of broadcasts originated by the node (returned by controllers or whispers)")
 	n.metrics.RegisterCounter(metricsUnknownBroadcast, "The total number of unrecognized messages received through PubSub")
+	n.metrics.RegisterCounter(metricsRestoredSessions, "The total number of sessions restored without calling RPC")
+	n.metrics.RegisterCounter(metricsRemoteDisconnects, "The total number of sessions disconnected via remote commands")
 
 	n.metrics.RegisterCounter(metricsSentMsg, "The total number of messages sent to clients")
 	n.metrics.RegisterCounter(metricsFailedSent, "The total number of messages failed to send to clients")
+	n.metrics.RegisterCounter(metricsDroppedMsg, "The total number of messages dropped due to full send queues")
 
 	n.metrics.RegisterCounter(metricsDataSent, "The total amount of bytes sent to clients")
 	n.metrics.RegisterCounter(metricsDataReceived, "The total amount of bytes received from clients")
 }
+
+func newNodeID() string {
+	id, err := nanoid.Nanoid()
+
+	if err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+
+	return id
+}
@@ -8,7 +8,6 @@ import (
 	"github.com/anycable/anycable-go/encoders"
 	"github.com/anycable/anycable-go/metrics"
 	"github.com/anycable/anycable-go/mocks"
-	"github.com/anycable/anycable-go/ws"
 
 	"github.com/apex/log"
 )
@@ -53,7 +52,7 @@ func (conn MockConnection) Read() ([]byte, error) {
 			case <-done:
 				return
 			case frame := <-conn.session.sendCh:
-				conn.session.writeFrame(frame) // nolint:errcheck
+				conn.session.writeFrame(frame.frame) // nolint:errcheck
 			}
 		}
 	}()
@@ -80,7 +79,7 @@ func (conn MockConnection) ReadIndifinitely() []byte {
 			case <-done:
 				return
 			case frame := <-conn.session.sendCh:
-				conn.session.writeFrame(frame) // nolint:errcheck
+				conn.session.writeFrame(frame.frame) // nolint:errcheck
 			}
 		}
 	}()
@@ -110,7 +109,7 @@ func NewMockSession(uid string, node *Node) *Session {
 		Log:           log.WithField("sid", uid),
 		subscriptions: NewSubscriptionState(),
 		env:           common.NewSessionEnv("/cable-test", &map[string]string{}),
-		sendCh:        make(chan *ws.SentFrame, 256),
+		sendCh:        make(chan *queuedFrame, 256),
 		encoder:       encoders.JSON{},
 		metrics:       metrics.NoopMetrics{},
 	}
@@ -2,11 +2,19 @@ package node
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/anycable/anycable-go/common"
 	"github.com/anycable/anycable-go/encoders"
+	"github.com/anycable/anycable-go/hub"
+	"github.com/anycable/anycable-go/metrics"
+	"github.com/anycable/anycable-go/mocks"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
@@ -71,6 +79,150 @@ func TestAuthenticate(t *testing.T) {
 	})
 }
 
+func TestRestoreSession(t *testing.T) {
+	node := NewMockNode()
+	node.sessions = NewSessionsCache(time.Minute)
+
+	go node.hub.Run()
+	defer node.hub.Shutdown()
+
+	prev := NewMockSessionWithEnv("1", node, "/cable", &map[string]string{"id": "test_id"})
+	_, err := node.Authenticate(prev)
+	require.NoError(t, err)
+
+	_, err = prev.conn.Read()
+	require.NoError(t, err)
+
+	prev.restoreToken = "secret-token"
+	prev.subscriptions.AddChannel("test_channel")
+	prev.subscriptions.AddChannelStream("test_channel", "streamo")
+	prev.env.MergeConnectionState(&map[string]string{"_s_": "saved"})
+
+	require.NoError(t, node.Disconnect(prev))
+
+	assert.Equal(t, 0, node.disconnector.Size())
+	assert.Equal(t, 1, node.sessions.Size())
+
+	t.Run("Restores session by token", func(t *testing.T) {
+		session := NewMockSessionWithEnv("2", node, "/cable?restore_token=secret-token", &map[string]string{})
+
+		res, err := node.Authenticate(session)
+		require.NoError(t, err)
+
+		assert.Equal(t, common.SUCCESS, res.Status)
+		assert.True(t, session.Connected)
+		assert.Equal(t, "test_id", session.GetIdentifiers())
+		assert.Equal(t, "saved", session.env.GetConnectionStateField("_s_"))
+		assert.Equal(t, []string{"streamo"}, session.subscriptions.StreamsFor("test_channel"))
+
+		msg, err := session.conn.Read()
+		require.NoError(t, err)
+
+		var reply common.Reply
+		require.NoError(t, json.Unmarshal(msg, &reply))
+
+		assert.Equal(t, common.SessionRestoredType, reply.Type)
+		assert.NotEmpty(t, reply.RestoreToken)
+		assert.Equal(t, reply.RestoreToken, session.restoreToken)
+
+		node.hub.Broadcast("streamo", "42")
+
+		msg, err = session.conn.Read()
+		require.NoError(t, err)
+
+		assert.Equal(t, `{"identifier":"test_channel","message":42}`, string(msg))
+	})
+
+	t.Run("Authenticates when token is unknown", func(t *testing.T) {
+		session := NewMockSessionWithEnv("3", node, "/cable?restore_token=secret-token", &map[string]string{"id": "another_id"})
+
+		_, err := node.Authenticate(session)
+		require.NoError(t, err)
+
+		assert.Equal(t, "another_id", session.GetIdentifiers())
+
+		msg, err := session.conn.Read()
+		require.NoError(t, err)
+
+		assert.Equal(t, "welcome", string(msg))
+	})
+}
+
+func TestRestoreExpiredSession(t *testing.T) {
+	controller := mocks.Controller{}
+	config := NewConfig()
+	node := NewNode(&controller, metrics.NewMetrics(nil, 10), &config)
+	dconfig := NewDisconnectQueueConfig()
+	node.SetDisconnector(NewDisconnectQueue(node, &dconfig))
+	node.sessions = NewSessionsCache(time.Minute)
+
+	prev := NewMockSessionWithEnv("1", node, "/cable", &map[string]string{})
+	prev.restoreToken = "stale-token"
+
+	node.sessions.Store(prev)
+	node.sessions.sessions["stale-token"].deadline = time.Now().Add(-time.Second)
+
+	controller.On("Authenticate", "2", mock.Anything).Return(&common.ConnectResult{Identifier: "test_id", Status: common.SUCCESS, Transmissions: []string{"welcome"}}, nil)
+	controller.On("Disconnect", "1", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	session := NewMockSessionWithEnv("2", node, "/cable?restore_token=stale-token", &map[string]string{})
+
+	res, err := node.Authenticate(session)
+	require.NoError(t, err)
+
+	assert.Equal(t, common.SUCCESS, res.Status)
+	assert.Equal(t, "test_id", session.GetIdentifiers())
+	assert.Empty(t, node.sessions.Expire())
+
+	require.NoError(t, node.disconnector.Shutdown())
+
+	controller.AssertNumberOfCalls(t, "Disconnect", 1)
+	controller.AssertCalled(t, "Disconnect", "1", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRestoreSessionWhileDraining(t *testing.T) {
+	node := NewMockNode()
+	node.sessions = NewSessionsCache(time.Minute)
+	node.draining = 1
+
+	go node.hub.Run()
+	defer node.hub.Shutdown()
+
+	prev := NewMockSessionWithEnv("1", node, "/cable", &map[string]string{"id": "test_id"})
+	prev.restoreToken = "secret-token"
+
+	node.sessions.Store(prev)
+
+	session := NewMockSessionWithEnv("2", node, "/cable?restore_token=secret-token", &map[string]string{"id": "another_id"})
+
+	_, err := node.Authenticate(session)
+	require.NoError(t, err)
+
+	assert.Equal(t, "another_id", session.GetIdentifiers())
+
+	msg, err := session.conn.Read()
+	require.NoError(t, err)
+
+	assert.Equal(t, "welcome", string(msg))
+
+	assert.Equal(t, 0, node.sessions.Size())
+	assert.Equal(t, 1, node.disconnector.Size())
+
+	task := <-node.disconnector.(*DisconnectQueue).disconnect
+	assert.Equal(t, prev, task)
+}
+
+func TestAssignRestoreToken(t *testing.T) {
+	node := NewMockNode()
+	session := NewMockSession("14", node)
+
+	transmissions := node.assignRestoreToken(session, []string{`{"type":"welcome"}`, `{"type":"confirm_subscription"}`})
+
+	assert.NotEmpty(t, session.restoreToken)
+	assert.Equal(t, fmt.Sprintf(`{"restore_token":"%s","type":"welcome"}`, session.restoreToken), transmissions[0])
+	assert.Equal(t, `{"type":"confirm_subscription"}`, transmissions[1])
+}
+
 func TestSubscribe(t *testing.T) {
 	node := NewMockNode()
 	session := NewMockSession("14", node)
@@ -247,6 +399,369 @@ func TestPerform(t *testing.T) {
 		assert.Len(t, (*session.env.ChannelStates)["test_channel"], 1)
 		assert.Equal(t, "performed", (*session.env.ChannelStates)["test_channel"]["_c_"])
 	})
+
+	t.Run("With broadcast to others", func(t *testing.T) {
+		session2 := NewMockSession("15", node)
+		node.hub.AddSession(session2)
+		defer node.hub.RemoveSession(session2)
+
+		node.hub.SubscribeSession("14", "all", "test_channel")
+		node.hub.SubscribeSession("15", "all", "test_channel")
+
+		_, err := node.Perform(session, &common.Message{Identifier: "test_channel", Data: "broadcast_to_others"})
+		assert.Nil(t, err)
+
+		msg, err := session2.conn.Read()
+		assert.Nil(t, err)
+		assert.Equal(t, "{\"identifier\":\"test_channel\",\"message\":\"hey\"}", string(msg))
+
+		_, err = session.conn.Read()
+		assert.Error(t, err, "Caller must not receive the broadcast")
+	})
+}
+
+func TestBroadcastsPropagation(t *testing.T) {
+	node := NewMockNode()
+	publisher := &testPublisher{}
+	node.SetPublisher(publisher)
+
+	session := NewMockSession("14", node)
+	session2 := NewMockSession("15", node)
+
+	for _, s := range []*Session{session, session2} {
+		node.hub.AddSession(s)
+		s.subscriptions.AddChannel("test_channel")
+		node.hub.SubscribeSession(s.GetID(), "all", "test_channel")
+	}
+
+	go node.hub.Run()
+	defer node.hub.Shutdown()
+
+	_, err := node.Perform(session, &common.Message{Identifier: "test_channel", Data: "broadcast_to_others"})
+	require.NoError(t, err)
+
+	msg, err := session2.conn.Read()
+	require.NoError(t, err)
+	assert.Equal(t, "{\"identifier\":\"test_channel\",\"message\":\"hey\"}", string(msg))
+
+	messages := publisher.Messages()
+	require.Len(t, messages, 1)
+
+	var published common.StreamMessage
+	require.NoError(t, json.Unmarshal([]byte(messages[0]), &published))
+
+	assert.Equal(t, "all", published.Stream)
+	assert.Equal(t, "\"hey\"", published.Data)
+	assert.Equal(t, "14", published.ExcludeSocket)
+	assert.Equal(t, node.id, published.Node)
+
+	t.Run("Skips own messages received via pub/sub", func(t *testing.T) {
+		node.HandlePubSub([]byte(messages[0]))
+
+		_, err := session2.conn.Read()
+		assert.Error(t, err)
+	})
+
+	t.Run("Delivers messages from other nodes", func(t *testing.T) {
+		node.HandlePubSub([]byte(`{"stream":"all","data":"\"hi\"","node":"other"}`))
+
+		msg, err := session2.conn.Read()
+		require.NoError(t, err)
+		assert.Equal(t, "{\"identifier\":\"test_channel\",\"message\":\"hi\"}", string(msg))
+	})
+}
+
+func TestHistory(t *testing.T) {
+	node := NewMockNode()
+	node.hub.EnableHistory(2, time.Minute)
+
+	session := NewMockSession("14", node)
+
+	node.hub.AddSession(session)
+	defer node.hub.RemoveSession(session)
+
+	session.subscriptions.AddChannel("test_channel")
+	session.subscriptions.AddChannelStream("test_channel", "streamo")
+	node.hub.SubscribeSession("14", "streamo", "test_channel")
+
+	go node.hub.Run()
+	defer node.hub.Shutdown()
+
+	var epoch string
+
+	for i := 1; i <= 3; i++ {
+		node.hub.Broadcast("streamo", fmt.Sprintf("%d", i))
+
+		msg, err := session.conn.Read()
+		require.NoError(t, err)
+
+		var reply common.Reply
+		require.NoError(t, json.Unmarshal(msg, &reply))
+
+		epoch = reply.Epoch
+	}
+
+	t.Run("Successful history retrieval", func(t *testing.T) {
+		err := node.HandleCommand(session, &common.Message{
+			Command:    "history",
+			Identifier: "test_channel",
+			History:    &common.HistoryRequest{Streams: map[string]common.HistoryPosition{"streamo": {Epoch: epoch, Offset: 2}}},
+		})
+		require.NoError(t, err)
+
+		msg, err := session.conn.Read()
+		require.NoError(t, err)
+
+		assert.Equal(t, fmt.Sprintf(`{"identifier":"test_channel","message":3,"stream_id":"streamo","offset":3,"epoch":"%s"}`, epoch), string(msg))
+
+		msg, err = session.conn.Read()
+		require.NoError(t, err)
+
+		assert.Equal(t, `{"type":"confirm_history","identifier":"test_channel"}`, string(msg))
+	})
+
+	t.Run("When offset has been evicted", func(t *testing.T) {
+		err := node.HandleCommand(session, &common.Message{
+			Command:    "history",
+			Identifier: "test_channel",
+			History:    &common.HistoryRequest{Streams: map[string]common.HistoryPosition{"streamo": {Epoch: epoch, Offset: 0}}},
+		})
+		require.Error(t, err)
+
+		msg, err := session.conn.Read()
+		require.NoError(t, err)
+
+		assert.Equal(t, `{"type":"reject_history","identifier":"test_channel","reason":"Couldn't retrieve history for streamo: Requested offset has been evicted from the history"}`, string(msg))
+	})
+
+	t.Run("When not subscribed", func(t *testing.T) {
+		err := node.HandleCommand(session, &common.Message{Command: "history", Identifier: "unknown", History: &common.HistoryRequest{Since: 1}})
+		assert.Error(t, err)
+	})
+}
+
+type testPublisher struct {
+	messages [][]byte
+	mu       sync.Mutex
+}
+
+func (p *testPublisher) Publish(msg []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.messages = append(p.messages, msg)
+	return nil
+}
+
+func (p *testPublisher) Messages() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	res := make([]string, len(p.messages))
+
+	for i, msg := range p.messages {
+		res[i] = string(msg)
+	}
+
+	return res
+}
+
+func TestPresence(t *testing.T) {
+	node := NewMockNode()
+	publisher := &testPublisher{}
+	node.SetPublisher(publisher)
+
+	session := NewMockSession("14", node)
+	session2 := NewMockSession("15", node)
+
+	for _, s := range []*Session{session, session2} {
+		node.hub.AddSession(s)
+		s.subscriptions.AddChannel("test_channel")
+		s.subscriptions.AddChannelStream("test_channel", "streamo")
+		node.hub.SubscribeSession(s.GetID(), "streamo", "test_channel")
+	}
+
+	go node.hub.Run()
+	defer node.hub.Shutdown()
+
+	t.Run("Join", func(t *testing.T) {
+		err := node.HandleCommand(session, &common.Message{
+			Command:    "join",
+			Identifier: "test_channel",
+			Presence:   &common.PresenceRecord{ID: "42", Info: "Jack"},
+		})
+		require.NoError(t, err)
+
+		msg, err := session2.conn.Read()
+		require.NoError(t, err)
+
+		assert.Equal(t, `{"type":"presence","identifier":"test_channel","message":{"type":"join","id":"42","info":"Jack"}}`, string(msg))
+
+		assert.Equal(t, []string{
+			fmt.Sprintf(`{"command":"presence","payload":{"node":"%s","event":"join","records":[{"stream":"streamo","id":"42","info":"Jack"}]}}`, node.id),
+		}, publisher.Messages())
+	})
+
+	t.Run("Join without ID", func(t *testing.T) {
+		err := node.HandleCommand(session, &common.Message{Command: "join", Identifier: "test_channel"})
+		assert.Error(t, err)
+	})
+
+	t.Run("Remote join", func(t *testing.T) {
+		node.HandlePubSub([]byte(`{"command":"presence","payload":{"node":"other","event":"join","records":[{"stream":"streamo","id":"2022"}]}}`))
+
+		msg, err := session.conn.Read()
+		require.NoError(t, err)
+
+		assert.Equal(t, `{"type":"presence","identifier":"test_channel","message":{"type":"join","id":"2022"}}`, string(msg))
+
+		_, err = session2.conn.Read()
+		require.NoError(t, err)
+	})
+
+	t.Run("Ignores own messages", func(t *testing.T) {
+		node.HandlePubSub([]byte(fmt.Sprintf(`{"command":"presence","payload":{"node":"%s","event":"join","records":[{"stream":"streamo","id":"1"}]}}`, node.id)))
+
+		_, err := session.conn.Read()
+		assert.Error(t, err)
+	})
+
+	t.Run("Query", func(t *testing.T) {
+		err := node.HandleCommand(session2, &common.Message{Command: "presence", Identifier: "test_channel"})
+		require.NoError(t, err)
+
+		msg, err := session2.conn.Read()
+		require.NoError(t, err)
+
+		assert.Equal(t, `{"type":"presence","identifier":"test_channel","message":{"type":"info","total":2,"records":[{"id":"2022"},{"id":"42","info":"Jack"}]}}`, string(msg))
+	})
+
+	t.Run("Leave", func(t *testing.T) {
+		err := node.HandleCommand(session, &common.Message{Command: "leave", Identifier: "test_channel"})
+		require.NoError(t, err)
+
+		msg, err := session2.conn.Read()
+		require.NoError(t, err)
+
+		assert.Equal(t, `{"type":"presence","identifier":"test_channel","message":{"type":"leave","id":"42"}}`, string(msg))
+		assert.Len(t, publisher.Messages(), 2)
+	})
+
+	t.Run("When not subscribed", func(t *testing.T) {
+		err := node.HandleCommand(session, &common.Message{Command: "presence", Identifier: "unknown"})
+		assert.Error(t, err)
+	})
+}
+
+func TestWhisper(t *testing.T) {
+	node := NewMockNode()
+	node.config.WhisperRateLimit = 2
+	node.hub.EnableHistory(10, time.Minute)
+
+	session := NewMockSession("14", node)
+	session2 := NewMockSession("15", node)
+
+	for _, s := range []*Session{session, session2} {
+		node.hub.AddSession(s)
+		s.subscriptions.AddChannel("test_channel")
+		s.subscriptions.AddChannelStream("test_channel", "streamo")
+		node.hub.SubscribeSession(s.GetID(), "streamo", "test_channel")
+	}
+
+	go node.hub.Run()
+	defer node.hub.Shutdown()
+
+	t.Run("When whispering is not enabled", func(t *testing.T) {
+		err := node.HandleCommand(session, &common.Message{Command: "whisper", Identifier: "test_channel", Data: "hi"})
+		assert.Error(t, err)
+
+		_, err = session2.conn.Read()
+		assert.Error(t, err)
+	})
+
+	session.env.MergeChannelState("test_channel", &map[string]string{"$w": "1"})
+
+	t.Run("When whispering is enabled", func(t *testing.T) {
+		err := node.HandleCommand(session, &common.Message{
+			Command:    "whisper",
+			Identifier: "test_channel",
+			Data:       map[string]interface{}{"event": "typing"},
+		})
+		require.NoError(t, err)
+
+		msg, err := session2.conn.Read()
+		require.NoError(t, err)
+
+		assert.Equal(t, `{"identifier":"test_channel","message":{"event":"typing"}}`, string(msg))
+
+		_, err = session.conn.Read()
+		assert.Error(t, err)
+	})
+
+	t.Run("When rate limit is exceeded", func(t *testing.T) {
+		err := node.HandleCommand(session, &common.Message{Command: "whisper", Identifier: "test_channel", Data: "1"})
+		require.NoError(t, err)
+
+		err = node.HandleCommand(session, &common.Message{Command: "whisper", Identifier: "test_channel", Data: "2"})
+		assert.Error(t, err)
+
+		msg, err := session2.conn.Read()
+		require.NoError(t, err)
+
+		assert.Equal(t, `{"identifier":"test_channel","message":1}`, string(msg))
+	})
+
+	t.Run("Whispers are not stored in history", func(t *testing.T) {
+		err := node.HandleCommand(session2, &common.Message{
+			Command:    "history",
+			Identifier: "test_channel",
+			History:    &common.HistoryRequest{Since: time.Now().Add(-time.Minute).Unix()},
+		})
+		require.NoError(t, err)
+
+		msg, err := session2.conn.Read()
+		require.NoError(t, err)
+
+		assert.Equal(t, `{"type":"confirm_history","identifier":"test_channel"}`, string(msg))
+	})
+
+	t.Run("When not subscribed", func(t *testing.T) {
+		err := node.HandleCommand(session, &common.Message{Command: "whisper", Identifier: "unknown", Data: "hi"})
+		assert.Error(t, err)
+	})
+}
+
+func TestSessionsIntrospection(t *testing.T) {
+	node := NewMockNode()
+	session := NewMockSession("14", node)
+	session.Connected = true
+
+	node.hub.AddSession(session)
+	session.subscriptions.AddChannel("test_channel")
+	session.subscriptions.AddChannelStream("test_channel", "streamo")
+	node.hub.SubscribeSession(session.GetID(), "streamo", "test_channel")
+	session.env.MergeConnectionState(&map[string]string{"user": "jack"})
+	session.env.MergeChannelState("test_channel", &map[string]string{"room": "42"})
+
+	assert.Equal(t, []string{"14"}, node.SessionIDs())
+	assert.Equal(t, []*hub.StreamInfo{{Stream: "streamo", Sessions: 1}}, node.Streams())
+
+	info := node.SessionInfo("14")
+	require.NotNil(t, info)
+
+	assert.Equal(t, &SessionInfo{
+		ID:              "14",
+		Identifiers:     "14",
+		Channels:        map[string][]string{"test_channel": {"streamo"}},
+		ConnectionState: map[string]string{"user": "jack"},
+		ChannelStates:   map[string]map[string]string{"test_channel": {"room": "42"}},
+		Connected:       true,
+	}, info)
+
+	assert.Nil(t, node.SessionInfo("15"))
+
+	assert.Len(t, node.SessionsInfoByIdentifiers("14"), 1)
+	assert.Empty(t, node.SessionsInfoByIdentifiers("15"))
 }
 
 func TestStreamSubscriptionRaceConditions(t *testing.T) {
@@ -290,6 +805,97 @@ func TestDisconnect(t *testing.T) {
 	assert.Equal(t, node.hub.Size(), 0)
 }
 
+func TestDrain(t *testing.T) {
+	t.Run("When drain period is not configured", func(t *testing.T) {
+		node := NewMockNode()
+
+		assert.Nil(t, node.Drain())
+		assert.False(t, node.Draining())
+	})
+
+	t.Run("Closes all sessions within the drain period", func(t *testing.T) {
+		node := NewMockNode()
+		node.config.ShutdownDrainPeriod = 1
+		node.config.ShutdownReconnectDelay = 500
+
+		go node.hub.Run()
+		defer node.hub.Shutdown()
+
+		queues := []chan *queuedFrame{}
+
+		for _, sid := range []string{"s1", "s2", "s3"} {
+			session := NewMockSession(sid, node)
+			node.hub.AddSession(session)
+			queues = append(queues, session.sendCh)
+		}
+
+		start := time.Now()
+
+		require.NoError(t, node.Drain())
+
+		assert.True(t, node.Draining())
+		assert.Less(t, time.Since(start), 2*time.Second)
+		assert.Error(t, node.Drain())
+
+		for _, queue := range queues {
+			frame := <-queue
+
+			var msg common.DisconnectMessage
+			require.NoError(t, json.Unmarshal(frame.frame.Payload, &msg))
+
+			assert.Equal(t, "disconnect", msg.Type)
+			assert.Equal(t, common.SERVER_RESTART_REASON, msg.Reason)
+			assert.True(t, msg.Reconnect)
+			assert.LessOrEqual(t, msg.ReconnectDelay, 500)
+
+			frame = <-queue
+			assert.True(t, frame.isClose())
+		}
+	})
+}
+
+func TestNotifyBroadcastState(t *testing.T) {
+	node := NewMockNode()
+	node.config.BroadcastDegradedNotice = true
+
+	go node.hub.Run()
+	defer node.hub.Shutdown()
+
+	session := NewMockSession("14", node)
+	node.hub.AddSession(session)
+
+	// Restoring notice is not sent unless degradation has been reported
+	node.NotifyBroadcastState(true)
+
+	_, err := session.conn.Read()
+	assert.Error(t, err)
+
+	node.NotifyBroadcastState(false)
+	node.NotifyBroadcastState(false)
+
+	msg, err := session.conn.Read()
+	require.NoError(t, err)
+	assert.Equal(t, `{"type":"broadcast_degraded"}`, string(msg))
+
+	_, err = session.conn.Read()
+	assert.Error(t, err)
+
+	node.NotifyBroadcastState(true)
+
+	msg, err = session.conn.Read()
+	require.NoError(t, err)
+	assert.Equal(t, `{"type":"broadcast_restored"}`, string(msg))
+
+	t.Run("When notice is disabled", func(t *testing.T) {
+		node.config.BroadcastDegradedNotice = false
+
+		node.NotifyBroadcastState(false)
+
+		_, err := session.conn.Read()
+		assert.Error(t, err)
+	})
+}
+
 func TestHandlePubSub(t *testing.T) {
 	node := NewMockNode()
 
@@ -318,6 +924,31 @@ func TestHandlePubSub(t *testing.T) {
 	assert.Equalf(t, expected, string(msg2), "Expected to receive %s but got %s", expected, string(msg2))
 }
 
+func TestHandlePubSubWithExcludedSocket(t *testing.T) {
+	node := NewMockNode()
+
+	go node.hub.Run()
+	defer node.hub.Shutdown()
+
+	session := NewMockSession("14", node)
+	session2 := NewMockSession("15", node)
+
+	node.hub.AddSession(session)
+	node.hub.SubscribeSession("14", "test", "test_channel")
+
+	node.hub.AddSession(session2)
+	node.hub.SubscribeSession("15", "test", "test_channel")
+
+	node.HandlePubSub([]byte("{\"stream\":\"test\",\"data\":\"\\\"abc123\\\"\",\"exclude_socket\":\"14\"}"))
+
+	msg, err := session2.conn.Read()
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"identifier\":\"test_channel\",\"message\":\"abc123\"}", string(msg))
+
+	_, err = session.conn.Read()
+	assert.Error(t, err)
+}
+
 func TestHandlePubSubWithCommand(t *testing.T) {
 	node := NewMockNode()
 
@@ -337,6 +968,179 @@ func TestHandlePubSubWithCommand(t *testing.T) {
 	assert.True(t, session.closed)
 }
 
+func TestHandlePubSubWithDisconnectAck(t *testing.T) {
+	node := NewMockNode()
+	publisher := &testPublisher{}
+	node.SetPublisher(publisher)
+
+	go node.hub.Run()
+	defer node.hub.Shutdown()
+
+	session := NewMockSession("14", node)
+	session2 := NewMockSession("15", node)
+	node.hub.AddSession(session)
+	node.hub.AddSession(session2)
+
+	node.HandlePubSub([]byte(`{"command":"disconnect","payload":{"sid":"15","reconnect":true,"ack_id":"a1"}}`))
+
+	msg, err := session2.conn.Read()
+	require.NoError(t, err)
+	assert.Equal(t, string(toJSON(common.NewDisconnectMessage("remote", true))), string(msg))
+
+	_, err = session.conn.Read()
+	assert.Error(t, err)
+
+	assert.Equal(t, []string{
+		fmt.Sprintf(`{"command":"disconnect_ack","payload":{"ack_id":"a1","node":"%s","sessions":1}}`, node.id),
+	}, publisher.Messages())
+
+	// Nodes ignore acks
+	node.HandlePubSub([]byte(publisher.Messages()[0]))
+	assert.Len(t, publisher.Messages(), 1)
+}
+
+func TestHandlePubSubWithDisconnectAckWithoutPublisher(t *testing.T) {
+	node := NewMockNode()
+
+	go node.hub.Run()
+	defer node.hub.Shutdown()
+
+	session := NewMockSession("15", node)
+	node.hub.AddSession(session)
+
+	// Acknowledgement is skipped (with a warning), but sessions are still disconnected
+	node.HandlePubSub([]byte(`{"command":"disconnect","payload":{"sid":"15","reconnect":true,"ack_id":"a1"}}`))
+
+	msg, err := session.conn.Read()
+	require.NoError(t, err)
+	assert.Equal(t, string(toJSON(common.NewDisconnectMessage("remote", true))), string(msg))
+}
+
+func TestHandlePubSubWithStreamCommands(t *testing.T) {
+	node := NewMockNode()
+
+	go node.hub.Run()
+	defer node.hub.Shutdown()
+
+	session := NewMockSession("14", node)
+	session2 := NewMockSession("15", node)
+
+	for _, s := range []*Session{session, session2} {
+		node.hub.AddSession(s)
+		s.subscriptions.AddChannel("test_channel")
+		s.subscriptions.AddChannelStream("test_channel", "test")
+		node.hub.SubscribeSession(s.GetID(), "test", "test_channel")
+	}
+
+	t.Run("Start stream", func(t *testing.T) {
+		node.HandlePubSub([]byte(`{"command":"stream_start","payload":{"identifier":"14","channel":"test_channel","stream":"secret"}}`))
+
+		assert.Contains(t, session.subscriptions.StreamsFor("test_channel"), "secret")
+		assert.NotContains(t, session2.subscriptions.StreamsFor("test_channel"), "secret")
+
+		node.hub.Broadcast("secret", "42")
+
+		msg, err := session.conn.Read()
+		require.NoError(t, err)
+		assert.Equal(t, `{"identifier":"test_channel","message":42}`, string(msg))
+
+		_, err = session2.conn.Read()
+		assert.Error(t, err)
+	})
+
+	t.Run("Start stream for unknown channel", func(t *testing.T) {
+		node.HandlePubSub([]byte(`{"command":"stream_start","payload":{"identifier":"14","channel":"unknown","stream":"secret2"}}`))
+
+		assert.False(t, session.subscriptions.HasChannel("unknown"))
+	})
+
+	t.Run("Stop stream", func(t *testing.T) {
+		node.HandlePubSub([]byte(`{"command":"stream_stop","payload":{"identifier":"14","channel":"test_channel","stream":"secret"}}`))
+
+		assert.NotContains(t, session.subscriptions.StreamsFor("test_channel"), "secret")
+		assert.True(t, session.subscriptions.HasChannel("test_channel"))
+
+		node.hub.Broadcast("secret", "42")
+
+		_, err := session.conn.Read()
+		assert.Error(t, err)
+	})
+
+	t.Run("Stop channel", func(t *testing.T) {
+		node.HandlePubSub([]byte(`{"command":"stream_stop","payload":{"identifier":"14","channel":"test_channel"}}`))
+
+		msg, err := session.conn.Read()
+		require.NoError(t, err)
+		assert.Equal(t, `{"type":"unsubscribed","identifier":"test_channel"}`, string(msg))
+
+		assert.False(t, session.subscriptions.HasChannel("test_channel"))
+		assert.True(t, session2.subscriptions.HasChannel("test_channel"))
+
+		node.hub.Broadcast("test", "42")
+
+		_, err = session.conn.Read()
+		assert.Error(t, err)
+
+		_, err = session2.conn.Read()
+		assert.NoError(t, err)
+	})
+}
+
+func TestHandlePubSubWithStreamStopChannel(t *testing.T) {
+	controller := mocks.Controller{}
+	config := NewConfig()
+	node := NewNode(&controller, metrics.NewMetrics(nil, 10), &config)
+
+	go node.hub.Run()
+	defer node.hub.Shutdown()
+
+	session := NewMockSession("14", node)
+	node.hub.AddSession(session)
+
+	for _, channel := range []string{"test_channel", "failing_channel"} {
+		session.subscriptions.AddChannel(channel)
+		session.subscriptions.AddChannelStream(channel, channel+"_stream")
+		node.hub.SubscribeSession(session.GetID(), channel+"_stream", channel)
+	}
+
+	controller.On("Unsubscribe", "14", mock.Anything, "14", "test_channel").Return(&common.CommandResult{Status: common.SUCCESS}, nil)
+	controller.On("Unsubscribe", "14", mock.Anything, "14", "failing_channel").Return(nil, errors.New("Unsubscription failure"))
+
+	t.Run("Invokes the unsubscribe callback", func(t *testing.T) {
+		node.HandlePubSub([]byte(`{"command":"stream_stop","payload":{"identifier":"14","channel":"test_channel"}}`))
+
+		controller.AssertCalled(t, "Unsubscribe", "14", mock.Anything, "14", "test_channel")
+
+		msg, err := session.conn.Read()
+		require.NoError(t, err)
+		assert.Equal(t, `{"type":"unsubscribed","identifier":"test_channel"}`, string(msg))
+
+		assert.False(t, session.subscriptions.HasChannel("test_channel"))
+
+		node.hub.Broadcast("test_channel_stream", "42")
+
+		_, err = session.conn.Read()
+		assert.Error(t, err)
+	})
+
+	t.Run("Removes the subscription when the callback fails", func(t *testing.T) {
+		node.HandlePubSub([]byte(`{"command":"stream_stop","payload":{"identifier":"14","channel":"failing_channel"}}`))
+
+		controller.AssertCalled(t, "Unsubscribe", "14", mock.Anything, "14", "failing_channel")
+
+		msg, err := session.conn.Read()
+		require.NoError(t, err)
+		assert.Equal(t, `{"type":"unsubscribed","identifier":"failing_channel"}`, string(msg))
+
+		assert.False(t, session.subscriptions.HasChannel("failing_channel"))
+
+		node.hub.Broadcast("failing_channel_stream", "42")
+
+		_, err = session.conn.Read()
+		assert.Error(t, err)
+	})
+}
+
 func TestLookupSession(t *testing.T) {
 	node := NewMockNode()
 
@@ -0,0 +1,95 @@
+package node
+
+import (
+	"sync/atomic"
+
+	"github.com/anycable/anycable-go/common"
+	"github.com/anycable/anycable-go/encoders"
+	"github.com/anycable/anycable-go/ws"
+)
+
+// Slow consumer policies define what to do when a session send queue is full
+const (
+	// Disconnect the session
+	SendQueueDisconnect = "disconnect"
+	// Drop the oldest pending message to make room for the new one
+	SendQueueDropOldest = "drop_oldest"
+	// Drop the new message
+	SendQueueDropNewest = "drop_newest"
+	// Keep only the latest pending message per stream
+	// (the session is disconnected if there is nothing to coalesce)
+	SendQueueCoalesce = "coalesce"
+)
+
+// IsSendQueuePolicy returns true if the provided string is a known slow consumer policy
+func IsSendQueuePolicy(policy string) bool {
+	switch policy {
+	case SendQueueDisconnect, SendQueueDropOldest, SendQueueDropNewest, SendQueueCoalesce:
+		return true
+	}
+
+	return false
+}
+
+// queuedFrame is a send queue item
+type queuedFrame struct {
+	frame *ws.SentFrame
+	// Channel and stream the message belongs to (empty for non-broadcast messages, which are never coalesced)
+	stream string
+	// Control frames (e.g., disconnect messages) are never evicted from the queue
+	control bool
+}
+
+func (f *queuedFrame) isClose() bool {
+	return f.frame.FrameType == ws.CloseFrame
+}
+
+func (f *queuedFrame) isControl() bool {
+	return f.control || f.isClose()
+}
+
+// isControlMessage returns true if the message must be delivered regardless of the send queue policy
+func isControlMessage(msg encoders.EncodedMessage) bool {
+	if cached, ok := msg.(*encoders.CachedEncodedMessage); ok {
+		msg = cached.Target()
+	}
+
+	_, ok := msg.(*common.DisconnectMessage)
+
+	return ok
+}
+
+// streamKeyFor returns the key to coalesce messages by (channel and stream)
+func streamKeyFor(msg encoders.EncodedMessage) string {
+	if cached, ok := msg.(*encoders.CachedEncodedMessage); ok {
+		msg = cached.Target()
+	}
+
+	if reply, ok := msg.(*common.Reply); ok && reply.Stream != "" {
+		return reply.Identifier + "/" + reply.Stream
+	}
+
+	return ""
+}
+
+// highWaterMark tracks the max value observed since the last reset
+type highWaterMark struct {
+	val int64
+}
+
+func (m *highWaterMark) Track(val int) {
+	v := int64(val)
+
+	for {
+		current := atomic.LoadInt64(&m.val)
+
+		if v <= current || atomic.CompareAndSwapInt64(&m.val, current, v) {
+			return
+		}
+	}
+}
+
+// Reset returns the current value and resets it to zero
+func (m *highWaterMark) Reset() int {
+	return int(atomic.SwapInt64(&m.val, 0))
+}
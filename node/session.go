@@ -8,6 +8,7 @@ import (
 	"github.com/anycable/anycable-go/common"
 	"github.com/anycable/anycable-go/encoders"
 	"github.com/anycable/anycable-go/metrics"
+	"github.com/anycable/anycable-go/utils"
 	"github.com/anycable/anycable-go/ws"
 	"github.com/apex/log"
 )
@@ -146,6 +147,19 @@ func (st *SubscriptionState) StreamsFor(id string) []string {
 	return nil
 }
 
+// SessionInfo contains the session details (used for introspection)
+type SessionInfo struct {
+	ID          string `json:"id"`
+	Identifiers string `json:"identifiers"`
+	// Channels with their streams
+	Channels        map[string][]string          `json:"channels"`
+	ConnectionState map[string]string            `json:"cstate"`
+	ChannelStates   map[string]map[string]string `json:"istate"`
+	// The number of pending outgoing messages
+	SendQueue int  `json:"send_queue"`
+	Connected bool `json:"connected"`
+}
+
 // Session represents active client
 type Session struct {
 	conn          Connection
@@ -156,13 +170,21 @@ type Session struct {
 	env           *common.SessionEnv
 	subscriptions *SubscriptionState
 	closed        bool
+	// Token used to restore the session after reconnect (empty if restoring is not possible)
+	restoreToken string
+	// Limits the rate of whispers (created on the first whisper)
+	whisperLimiter *utils.RateLimiter
 
 	// Main mutex (for read/write and important session updates)
 	mu sync.Mutex
 	// Mutex for protocol-related state (env, subscriptions)
 	smu sync.Mutex
 
-	sendCh chan *ws.SentFrame
+	sendCh chan *queuedFrame
+	// What to do when the send queue is full
+	sendQueuePolicy string
+	// Tracks the send queue length (could be nil)
+	sendQueueMark *highWaterMark
 
 	pingTimer    *time.Timer
 	pingInterval time.Duration
@@ -182,7 +204,9 @@ func NewSession(node *Node, conn Connection, url string, headers *map[string]str
 		metrics:                node.metrics,
 		env:                    common.NewSessionEnv(url, headers),
 		subscriptions:          NewSubscriptionState(),
-		sendCh:                 make(chan *ws.SentFrame, 256),
+		sendCh:                 make(chan *queuedFrame, node.config.SendQueueSize),
+		sendQueuePolicy:        node.config.SendQueuePolicy,
+		sendQueueMark:          node.sendQueueMark,
 		closed:                 false,
 		Connected:              false,
 		pingInterval:           time.Duration(node.config.PingInterval) * time.Second,
@@ -259,6 +283,43 @@ func (s *Session) SetIdentifiers(ids string) {
 	s.env.Identifiers = ids
 }
 
+// Info returns a snapshot of the session details
+func (s *Session) Info() *SessionInfo {
+	info := &SessionInfo{
+		ID:              s.GetID(),
+		Channels:        s.subscriptions.ToMap(),
+		ConnectionState: make(map[string]string),
+		ChannelStates:   make(map[string]map[string]string),
+	}
+
+	s.smu.Lock()
+	info.Identifiers = s.env.Identifiers
+
+	if s.env.ConnectionState != nil {
+		for k, v := range *s.env.ConnectionState {
+			info.ConnectionState[k] = v
+		}
+	}
+
+	if s.env.ChannelStates != nil {
+		for id, state := range *s.env.ChannelStates {
+			info.ChannelStates[id] = make(map[string]string, len(state))
+
+			for k, v := range state {
+				info.ChannelStates[id][k] = v
+			}
+		}
+	}
+	s.smu.Unlock()
+
+	s.mu.Lock()
+	info.SendQueue = len(s.sendCh)
+	info.Connected = s.Connected
+	s.mu.Unlock()
+
+	return info
+}
+
 // Merge connection and channel states into current env.
 // This method locks the state for writing (so, goroutine-safe)
 func (s *Session) MergeEnv(env *common.SessionEnv) {
@@ -312,7 +373,7 @@ func (s *Session) SendMessages() {
 	defer s.disconnectNow("Write Failed", ws.CloseAbnormalClosure)
 
 	for message := range s.sendCh {
-		err := s.writeFrame(message)
+		err := s.writeFrame(message.frame)
 
 		if err != nil {
 			s.metrics.CounterIncrement(metricsFailedSent)
@@ -352,7 +413,13 @@ func (s *Session) ReadMessage(message []byte) error {
 func (s *Session) Send(msg encoders.EncodedMessage) {
 	if b, err := s.encodeMessage(msg); err == nil {
 		if b != nil {
-			s.sendFrame(b)
+			stream := ""
+
+			if s.sendQueuePolicy == SendQueueCoalesce {
+				stream = streamKeyFor(msg)
+			}
+
+			s.enqueueFrame(&queuedFrame{frame: b, stream: stream, control: isControlMessage(msg)})
 		}
 	} else {
 		s.Log.Warnf("Failed to encode message %v. Error: %v", msg, err)
@@ -390,6 +457,8 @@ func (s *Session) DisconnectWithMessage(msg encoders.EncodedMessage, code string
 		wsCode = ws.CloseGoingAway
 	case common.REMOTE_DISCONNECT_REASON:
 		reason = "Closed remotely"
+		// Remotely disconnected sessions must not be restored
+		s.restoreToken = ""
 	}
 
 	s.Disconnect(reason, wsCode)
@@ -447,6 +516,10 @@ func (s *Session) sendClose(reason string, code int) {
 }
 
 func (s *Session) sendFrame(message *ws.SentFrame) {
+	s.enqueueFrame(&queuedFrame{frame: message})
+}
+
+func (s *Session) enqueueFrame(message *queuedFrame) {
 	s.mu.Lock()
 
 	if s.sendCh == nil {
@@ -457,17 +530,135 @@ func (s *Session) sendFrame(message *ws.SentFrame) {
 	select {
 	case s.sendCh <- message:
 	default:
-		if s.sendCh != nil {
+		if !s.handleFullSendQueue(message) {
 			close(s.sendCh)
+			s.sendCh = nil
+
 			defer s.Disconnect("Write failed", ws.CloseAbnormalClosure)
 		}
+	}
 
-		s.sendCh = nil
+	if s.sendQueueMark != nil && s.sendCh != nil {
+		s.sendQueueMark.Track(len(s.sendCh))
 	}
 
 	s.mu.Unlock()
 }
 
+// handleFullSendQueue applies the slow consumer policy.
+// Returns false if the session must be disconnected.
+// Must be called with the session locked.
+func (s *Session) handleFullSendQueue(message *queuedFrame) bool {
+	switch s.sendQueuePolicy {
+	case SendQueueDropNewest:
+		// Control and close frames must be delivered anyway
+		if message.isControl() {
+			return s.dropOldestFrame(message)
+		}
+
+		s.metrics.CounterIncrement(metricsDroppedMsg)
+		return true
+	case SendQueueDropOldest:
+		return s.dropOldestFrame(message)
+	case SendQueueCoalesce:
+		return s.coalesceFrames(message)
+	default:
+		return false
+	}
+}
+
+// dropOldestFrame evicts the oldest data frame to make room for the message.
+// Control and close frames are never evicted; if there is nothing to evict, data messages are dropped.
+func (s *Session) dropOldestFrame(message *queuedFrame) bool {
+	pending := make([]*queuedFrame, 0, cap(s.sendCh)+1)
+
+	for drained := false; !drained; {
+		select {
+		case frame := <-s.sendCh:
+			pending = append(pending, frame)
+		default:
+			drained = true
+		}
+	}
+
+	evicted := false
+
+	for i, frame := range pending {
+		if !frame.isControl() {
+			pending = append(pending[:i], pending[i+1:]...)
+			evicted = true
+			break
+		}
+	}
+
+	switch {
+	case evicted:
+		s.metrics.CounterIncrement(metricsDroppedMsg)
+		pending = append(pending, message)
+	case message.isControl():
+		// The queue is full of control frames, so the session is disconnected if the message doesn't fit
+		pending = append(pending, message)
+	default:
+		// Nothing to evict, drop the message itself
+		s.metrics.CounterIncrement(metricsDroppedMsg)
+	}
+
+	for _, frame := range pending {
+		select {
+		case s.sendCh <- frame:
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// coalesceFrames removes pending broadcast messages superseded by newer messages for the same stream
+func (s *Session) coalesceFrames(message *queuedFrame) bool {
+	pending := make([]*queuedFrame, 0, cap(s.sendCh)+1)
+
+	for drained := false; !drained; {
+		select {
+		case frame := <-s.sendCh:
+			pending = append(pending, frame)
+		default:
+			drained = true
+		}
+	}
+
+	pending = append(pending, message)
+
+	latest := make(map[string]int)
+
+	for i, frame := range pending {
+		if frame.stream != "" {
+			latest[frame.stream] = i
+		}
+	}
+
+	dropped := 0
+
+	for i, frame := range pending {
+		if frame.stream != "" && latest[frame.stream] != i {
+			dropped++
+			continue
+		}
+
+		select {
+		case s.sendCh <- frame:
+		default:
+			return false
+		}
+	}
+
+	if dropped > 0 {
+		s.metrics.CounterAdd(metricsDroppedMsg, uint64(dropped))
+	}
+
+	return true
+}
+
 func (s *Session) writeFrame(message *ws.SentFrame) error {
 	return s.writeFrameWithDeadline(message, time.Now().Add(writeWait))
 }
@@ -5,8 +5,10 @@ import (
 	"testing"
 
 	"github.com/anycable/anycable-go/common"
+	"github.com/anycable/anycable-go/encoders"
 	"github.com/anycable/anycable-go/ws"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestSendRaceConditions(t *testing.T) {
@@ -88,6 +90,142 @@ func TestSessionDisconnect(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestSessionSendQueuePolicies(t *testing.T) {
+	node := NewMockNode()
+
+	newSession := func(policy string) *Session {
+		session := NewMockSession("123", node)
+		session.closed = false
+		session.sendCh = make(chan *queuedFrame, 2)
+		session.sendQueuePolicy = policy
+		return session
+	}
+
+	broadcast := func(s *Session, stream string, data string) {
+		s.Send(encoders.NewCachedEncodedMessage(
+			(&common.StreamMessage{Stream: stream, Data: data}).ToReplyFor("test_channel"),
+		))
+	}
+
+	pending := func(s *Session) []string {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		res := []string{}
+
+		for len(s.sendCh) > 0 {
+			frame := <-s.sendCh
+			res = append(res, string(frame.frame.Payload))
+		}
+
+		return res
+	}
+
+	t.Run("disconnect", func(t *testing.T) {
+		session := newSession(SendQueueDisconnect)
+
+		for _, data := range []string{"1", "2", "3"} {
+			broadcast(session, "a", data)
+		}
+
+		assert.Nil(t, session.sendCh)
+	})
+
+	t.Run("drop_newest", func(t *testing.T) {
+		session := newSession(SendQueueDropNewest)
+
+		for _, data := range []string{"1", "2", "3"} {
+			broadcast(session, "a", data)
+		}
+
+		assert.Equal(t, []string{
+			`{"identifier":"test_channel","message":1}`,
+			`{"identifier":"test_channel","message":2}`,
+		}, pending(session))
+	})
+
+	t.Run("drop_oldest", func(t *testing.T) {
+		session := newSession(SendQueueDropOldest)
+
+		for _, data := range []string{"1", "2", "3"} {
+			broadcast(session, "a", data)
+		}
+
+		assert.Equal(t, []string{
+			`{"identifier":"test_channel","message":2}`,
+			`{"identifier":"test_channel","message":3}`,
+		}, pending(session))
+	})
+
+	t.Run("drop_oldest keeps control frames", func(t *testing.T) {
+		session := newSession(SendQueueDropOldest)
+
+		broadcast(session, "a", "1")
+		session.Send(common.NewDisconnectMessage("remote", false))
+		broadcast(session, "a", "2")
+		session.sendClose("Closed remotely", ws.CloseNormalClosure)
+
+		frames := []*queuedFrame{}
+
+		for len(session.sendCh) > 0 {
+			frames = append(frames, <-session.sendCh)
+		}
+
+		require.Len(t, frames, 2)
+		assert.Equal(t, `{"type":"disconnect","reason":"remote","reconnect":false}`, string(frames[0].frame.Payload))
+		assert.True(t, frames[1].isClose())
+	})
+
+	t.Run("drop_oldest drops data when queue is full of control frames", func(t *testing.T) {
+		session := newSession(SendQueueDropOldest)
+
+		session.Send(common.NewDisconnectMessage("remote", false))
+		session.Send(common.NewDisconnectMessage("remote", true))
+		broadcast(session, "a", "1")
+
+		assert.Equal(t, []string{
+			`{"type":"disconnect","reason":"remote","reconnect":false}`,
+			`{"type":"disconnect","reason":"remote","reconnect":true}`,
+		}, pending(session))
+	})
+
+	t.Run("drop_newest keeps control frames", func(t *testing.T) {
+		session := newSession(SendQueueDropNewest)
+
+		broadcast(session, "a", "1")
+		broadcast(session, "a", "2")
+		session.Send(common.NewDisconnectMessage("remote", false))
+
+		assert.Equal(t, []string{
+			`{"identifier":"test_channel","message":2}`,
+			`{"type":"disconnect","reason":"remote","reconnect":false}`,
+		}, pending(session))
+	})
+
+	t.Run("coalesce", func(t *testing.T) {
+		session := newSession(SendQueueCoalesce)
+
+		broadcast(session, "a", "1")
+		broadcast(session, "b", "2")
+		broadcast(session, "a", "3")
+
+		assert.Equal(t, []string{
+			`{"identifier":"test_channel","message":2}`,
+			`{"identifier":"test_channel","message":3}`,
+		}, pending(session))
+	})
+
+	t.Run("coalesce when nothing to coalesce", func(t *testing.T) {
+		session := newSession(SendQueueCoalesce)
+
+		broadcast(session, "a", "1")
+		broadcast(session, "b", "2")
+		broadcast(session, "c", "3")
+
+		assert.Nil(t, session.sendCh)
+	})
+}
+
 func TestMergeEnv(t *testing.T) {
 	node := NewMockNode()
 	session := NewMockSession("123", node)
@@ -0,0 +1,101 @@
+package node
+
+import (
+	"sync"
+	"time"
+
+	nanoid "github.com/matoous/go-nanoid"
+)
+
+type cachedSession struct {
+	session  *Session
+	deadline time.Time
+}
+
+// SessionsCache keeps recently disconnected sessions to restore them on reconnect
+type SessionsCache struct {
+	ttl      time.Duration
+	sessions map[string]*cachedSession
+	mu       sync.Mutex
+}
+
+// NewSessionsCache builds a new SessionsCache struct
+func NewSessionsCache(ttl time.Duration) *SessionsCache {
+	return &SessionsCache{ttl: ttl, sessions: make(map[string]*cachedSession)}
+}
+
+// Store adds a session to the cache using its restore token as a key
+func (c *SessionsCache) Store(s *Session) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.sessions[s.restoreToken] = &cachedSession{session: s, deadline: time.Now().Add(c.ttl)}
+}
+
+// Fetch removes the session with the specified token from the cache and returns it.
+// Returns nil if there is no such session or it has been expired; the expired session
+// is returned as the second value (so it could be disconnected).
+func (c *SessionsCache) Fetch(token string) (*Session, *Session) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.sessions[token]
+
+	if !ok {
+		return nil, nil
+	}
+
+	delete(c.sessions, token)
+
+	if time.Now().After(entry.deadline) {
+		return nil, entry.session
+	}
+
+	return entry.session, nil
+}
+
+// Expire removes expired sessions from the cache and returns them
+func (c *SessionsCache) Expire() []*Session {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	expired := []*Session{}
+
+	for token, entry := range c.sessions {
+		if now.After(entry.deadline) {
+			expired = append(expired, entry.session)
+			delete(c.sessions, token)
+		}
+	}
+
+	return expired
+}
+
+// Flush removes all sessions from the cache and returns them
+func (c *SessionsCache) Flush() []*Session {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sessions := make([]*Session, 0, len(c.sessions))
+
+	for _, entry := range c.sessions {
+		sessions = append(sessions, entry.session)
+	}
+
+	c.sessions = make(map[string]*cachedSession)
+
+	return sessions
+}
+
+// Size returns the number of cached sessions
+func (c *SessionsCache) Size() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.sessions)
+}
+
+func newRestoreToken() (string, error) {
+	return nanoid.Nanoid()
+}
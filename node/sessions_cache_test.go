@@ -0,0 +1,64 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionsCache(t *testing.T) {
+	node := NewMockNode()
+	cache := NewSessionsCache(time.Minute)
+
+	session := NewMockSession("14", node)
+	session.restoreToken = "tok-14"
+
+	stale := NewMockSession("15", node)
+	stale.restoreToken = "tok-15"
+
+	cache.Store(session)
+	cache.Store(stale)
+
+	assert.Equal(t, 2, cache.Size())
+
+	t.Run("Fetch", func(t *testing.T) {
+		prev, expired := cache.Fetch("unknown")
+		assert.Nil(t, prev)
+		assert.Nil(t, expired)
+
+		prev, expired = cache.Fetch("tok-14")
+		assert.Equal(t, session, prev)
+		assert.Nil(t, expired)
+
+		// Session could be fetched only once
+		prev, _ = cache.Fetch("tok-14")
+		assert.Nil(t, prev)
+		assert.Equal(t, 1, cache.Size())
+	})
+
+	t.Run("Expire", func(t *testing.T) {
+		assert.Empty(t, cache.Expire())
+
+		cache.sessions["tok-15"].deadline = time.Now().Add(-time.Second)
+
+		prev, expired := cache.Fetch("tok-15")
+		assert.Nil(t, prev)
+		assert.Equal(t, stale, expired)
+		assert.Empty(t, cache.Expire())
+
+		cache.Store(stale)
+		cache.sessions["tok-15"].deadline = time.Now().Add(-time.Second)
+
+		assert.Equal(t, []*Session{stale}, cache.Expire())
+		assert.Equal(t, 0, cache.Size())
+	})
+
+	t.Run("Flush", func(t *testing.T) {
+		cache.Store(session)
+		cache.Store(stale)
+
+		assert.Len(t, cache.Flush(), 2)
+		assert.Equal(t, 0, cache.Size())
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/anycable/anycable-go/metrics"
 	"github.com/anycable/anycable-go/server"
 	"github.com/apex/log"
 )
@@ -23,6 +24,9 @@ type HTTPConfig struct {
 	Path string
 	// Secret token to authorize requests
 	Secret string
+	// Pub/sub adapter (redis, redisx, nats or jetstream) to re-publish received broadcasts to
+	// (so every node receives them from the bus)
+	Bus string
 }
 
 // NewHTTPConfig builds a new config for HTTP pub/sub
@@ -41,6 +45,12 @@ type HTTPSubscriber struct {
 	server     *server.HTTPServer
 	node       Handler
 	log        *log.Entry
+
+	// When the bus is configured, broadcasts are re-published via the publisher
+	// and delivered to the node by the bus subscriber
+	publisher Publisher
+	bus       Subscriber
+	busName   string
 }
 
 // NewHTTPSubscriber builds a new HTTPSubscriber struct
@@ -60,8 +70,69 @@ func NewHTTPSubscriber(node Handler, config *HTTPConfig) *HTTPSubscriber {
 	}
 }
 
+// NewHTTPBusSubscriber builds a new HTTPSubscriber struct re-publishing broadcasts
+// via the publisher and consuming them from the bus subscriber
+func NewHTTPBusSubscriber(node Handler, config *HTTPConfig, publisher Publisher, bus Subscriber) *HTTPSubscriber {
+	s := NewHTTPSubscriber(node, config)
+	s.publisher = publisher
+	s.bus = bus
+	s.busName = config.Bus
+
+	return s
+}
+
+// SetMetrics passes the instrumenter to the bus subscriber (if it reports metrics)
+func (s *HTTPSubscriber) SetMetrics(m metrics.Instrumenter) {
+	if instrumented, ok := s.bus.(Instrumentable); ok {
+		instrumented.SetMetrics(m)
+	}
+}
+
+// SubscribeStream passes the stream to the bus subscriber (if it uses stream channels)
+func (s *HTTPSubscriber) SubscribeStream(stream string) {
+	if streams, ok := s.bus.(StreamsSubscriber); ok {
+		streams.SubscribeStream(stream)
+	}
+}
+
+// UnsubscribeStream passes the stream to the bus subscriber (if it uses stream channels)
+func (s *HTTPSubscriber) UnsubscribeStream(stream string) {
+	if streams, ok := s.bus.(StreamsSubscriber); ok {
+		streams.UnsubscribeStream(stream)
+	}
+}
+
+// Ready returns the bus subscriber state (HTTP subscriber without a bus is always ready)
+func (s *HTTPSubscriber) Ready() bool {
+	if stateful, ok := s.bus.(StatefulSubscriber); ok {
+		return stateful.Ready()
+	}
+
+	return true
+}
+
+// OnStateChange passes the callback to the bus subscriber (if it tracks its state)
+func (s *HTTPSubscriber) OnStateChange(fn func(ready bool)) {
+	if stateful, ok := s.bus.(StatefulSubscriber); ok {
+		stateful.OnStateChange(fn)
+	}
+}
+
 // Start creates an HTTP server or attaches a handler to the existing one
+// (and connects to the bus if configured)
 func (s *HTTPSubscriber) Start(done chan (error)) error {
+	if s.bus != nil {
+		if err := s.publisher.Start(); err != nil {
+			return err
+		}
+
+		if err := s.bus.Start(done); err != nil {
+			return err
+		}
+
+		s.log.Infof("Re-publish broadcast requests to the %s bus", s.busName)
+	}
+
 	server, err := server.ForPort(strconv.Itoa(s.port))
 
 	if err != nil {
@@ -84,12 +155,17 @@ func (s *HTTPSubscriber) Start(done chan (error)) error {
 	return nil
 }
 
-// Shutdown stops the HTTP server
+// Shutdown stops the HTTP server (and disconnects from the bus)
 func (s *HTTPSubscriber) Shutdown() error {
 	if s.server != nil {
 		s.server.Shutdown() //nolint:errcheck
 	}
 
+	if s.bus != nil {
+		s.bus.Shutdown()       //nolint:errcheck
+		s.publisher.Shutdown() //nolint:errcheck
+	}
+
 	return nil
 }
 
@@ -116,7 +192,15 @@ func (s *HTTPSubscriber) Handler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	s.node.HandlePubSub(body)
+	if s.publisher != nil {
+		if err := s.publisher.Publish(body); err != nil {
+			s.log.Errorf("Failed to re-publish broadcast: %v", err)
+			w.WriteHeader(503)
+			return
+		}
+	} else {
+		s.node.HandlePubSub(body)
+	}
 
 	w.WriteHeader(201)
 }
@@ -2,6 +2,7 @@ package pubsub
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -82,3 +83,61 @@ func TestHttpHandler(t *testing.T) {
 		assert.Equal(t, http.StatusCreated, rr.Code)
 	})
 }
+
+type testPublisher struct {
+	messages [][]byte
+	err      error
+}
+
+func (p *testPublisher) Start() error {
+	return nil
+}
+
+func (p *testPublisher) Publish(msg []byte) error {
+	if p.err != nil {
+		return p.err
+	}
+
+	p.messages = append(p.messages, msg)
+	return nil
+}
+
+func (p *testPublisher) Shutdown() error {
+	return nil
+}
+
+func TestHttpHandlerWithBus(t *testing.T) {
+	handler := &mocks.Handler{}
+	publisher := &testPublisher{}
+	config := HTTPConfig{Bus: "redis"}
+	subscriber := NewHTTPBusSubscriber(handler, &config, publisher, NewRedisSubscriber(handler, &RedisConfig{}))
+
+	payload := `{"stream":"any_test","data":"123_test"}`
+
+	t.Run("Re-publishes broadcasts to the bus", func(t *testing.T) {
+		req, err := http.NewRequest("POST", "/", strings.NewReader(payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(subscriber.Handler).ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Equal(t, [][]byte{[]byte(payload)}, publisher.messages)
+	})
+
+	t.Run("Responds with 503 when failed to publish", func(t *testing.T) {
+		publisher.err = errors.New("connection refused")
+
+		req, err := http.NewRequest("POST", "/", strings.NewReader(payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(subscriber.Handler).ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	})
+}
@@ -0,0 +1,297 @@
+package pubsub
+
+import (
+	"sync"
+
+	"github.com/anycable/anycable-go/metrics"
+	"github.com/apex/log"
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	defaultJetStreamName    = "__anycable__"
+	defaultJetStreamMaxMsgs = 10000
+
+	metricsJetStreamLag = "jetstream_lag"
+)
+
+// JetStreamSubscriber consumes broadcasts from a NATS JetStream stream via an ordered ephemeral consumer
+// and keeps track of the last delivered sequence, so no messages are lost during reconnects
+// (unless they have been already removed from the stream)
+type JetStreamSubscriber struct {
+	node   Handler
+	config *NATSConfig
+
+	conn   *nats.Conn
+	js     nats.JetStreamContext
+	sub    *nats.Subscription
+	subMu  sync.Mutex
+	closed bool
+
+	// Stream sequence of the last delivered message
+	lastSeq uint64
+	started bool
+	seqMu   sync.Mutex
+
+	metrics metrics.Instrumenter
+	log     *log.Entry
+}
+
+var _ Subscriber = (*JetStreamSubscriber)(nil)
+
+// NewJetStreamSubscriber returns new JetStreamSubscriber struct
+func NewJetStreamSubscriber(node Handler, c *NATSConfig) *JetStreamSubscriber {
+	return &JetStreamSubscriber{
+		node:    node,
+		config:  c,
+		metrics: metrics.NoopMetrics{},
+		log:     log.WithFields(log.Fields{"context": "pubsub", "provider": "jetstream"}),
+	}
+}
+
+// SetMetrics sets the instrumenter to report the consumer lag to
+func (s *JetStreamSubscriber) SetMetrics(m metrics.Instrumenter) {
+	s.metrics = m
+	s.metrics.RegisterGauge(metricsJetStreamLag, "The number of JetStream broadcasts not yet delivered to the node")
+}
+
+// Start connects to NATS and starts consuming the stream
+// (consumer is re-created from the last delivered sequence on every reconnect;
+// reconnect attempts are unlimited, so the consumer is never left closed)
+func (s *JetStreamSubscriber) Start(done chan (error)) error {
+	connectOptions := []nats.Option{
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+		nats.DisconnectErrHandler(func(nc *nats.Conn, err error) {
+			if err != nil {
+				s.log.Warnf("Connection failed: %v", err)
+			}
+		}),
+		nats.ReconnectHandler(func(nc *nats.Conn) {
+			s.log.Infof("Connection restored: %s", nc.ConnectedUrl())
+
+			go func() {
+				if err := s.subscribe(); err != nil {
+					s.log.Errorf("Failed to resume consuming JetStream stream: %v", err)
+				}
+			}()
+		}),
+	}
+
+	if s.config.DontRandomizeServers {
+		connectOptions = append(connectOptions, nats.DontRandomize())
+	}
+
+	nc, err := nats.Connect(s.config.Servers, connectOptions...)
+
+	if err != nil {
+		return err
+	}
+
+	js, err := nc.JetStream()
+
+	if err != nil {
+		nc.Close()
+		return err
+	}
+
+	s.subMu.Lock()
+	s.conn = nc
+	s.js = js
+	s.subMu.Unlock()
+
+	// Otherwise, we subscribe when the connection is established
+	if !nc.IsConnected() {
+		return nil
+	}
+
+	if err := s.subscribe(); err != nil {
+		nc.Close()
+		return err
+	}
+
+	return nil
+}
+
+// Shutdown closes the connection (ephemeral consumer is removed by the server)
+func (s *JetStreamSubscriber) Shutdown() error {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+
+	s.closed = true
+
+	if s.conn != nil {
+		s.conn.Close()
+	}
+
+	return nil
+}
+
+// subscribe (re-)creates the ordered consumer starting right after the last delivered message
+func (s *JetStreamSubscriber) subscribe() error {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+
+	info, err := ensureJetStream(s.js, s.config, s.log)
+
+	if err != nil {
+		return err
+	}
+
+	s.seqMu.Lock()
+
+	if !s.started {
+		// Consume only new messages on the first start
+		s.lastSeq = info.State.LastSeq
+		s.started = true
+	} else if info.State.LastSeq < s.lastSeq {
+		s.log.Warnf("JetStream stream %s has been reset (last sequence: %d, last delivered: %d)", s.config.JetStreamName, info.State.LastSeq, s.lastSeq)
+		s.lastSeq = info.State.LastSeq
+	}
+
+	startSeq := s.lastSeq + 1
+
+	s.seqMu.Unlock()
+
+	if s.sub != nil {
+		// The consumer could be already gone, so we ignore errors
+		s.sub.Unsubscribe() // nolint:errcheck
+		s.sub = nil
+	}
+
+	sub, err := s.js.Subscribe(
+		s.config.Channel,
+		s.handleMessage,
+		nats.BindStream(s.config.JetStreamName),
+		nats.OrderedConsumer(),
+		nats.StartSequence(startSeq),
+	)
+
+	if err != nil {
+		return err
+	}
+
+	s.sub = sub
+
+	s.log.Infof("Consuming broadcasts from JetStream stream %s starting from sequence %d", s.config.JetStreamName, startSeq)
+
+	return nil
+}
+
+func (s *JetStreamSubscriber) handleMessage(m *nats.Msg) {
+	meta, err := m.Metadata()
+
+	if err != nil {
+		s.log.Warnf("Failed to read JetStream message metadata: %v", err)
+		return
+	}
+
+	s.seqMu.Lock()
+	defer s.seqMu.Unlock()
+
+	// Skip messages already delivered to the previous consumer
+	if meta.Sequence.Stream <= s.lastSeq {
+		return
+	}
+
+	s.lastSeq = meta.Sequence.Stream
+
+	s.log.Debugf("Incoming pubsub message from JetStream: %s", m.Data)
+	s.node.HandlePubSub(m.Data)
+
+	s.metrics.GaugeSet(metricsJetStreamLag, meta.NumPending)
+}
+
+// JetStreamPublisher publishes messages to the JetStream stream subject (waiting for acknowledgements)
+type JetStreamPublisher struct {
+	*NATSPublisher
+
+	js nats.JetStreamContext
+}
+
+var _ Publisher = (*JetStreamPublisher)(nil)
+
+// NewJetStreamPublisher returns new JetStreamPublisher struct
+func NewJetStreamPublisher(c *NATSConfig) *JetStreamPublisher {
+	publisher := NewNATSPublisher(c)
+	publisher.log = log.WithFields(log.Fields{"context": "pubsub", "provider": "jetstream"})
+
+	return &JetStreamPublisher{NATSPublisher: publisher}
+}
+
+// Start connects to NATS and creates the stream if necessary
+func (p *JetStreamPublisher) Start() error {
+	if err := p.NATSPublisher.Start(); err != nil {
+		return err
+	}
+
+	js, err := p.conn.JetStream()
+
+	if err != nil {
+		return err
+	}
+
+	p.js = js
+
+	if p.conn.IsConnected() {
+		if _, err := ensureJetStream(js, p.config, p.log); err != nil {
+			p.log.Warnf("Failed to initialize JetStream stream: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// Publish adds the message to the stream (creating the stream if it doesn't exist)
+func (p *JetStreamPublisher) Publish(msg []byte) error {
+	_, err := p.js.Publish(p.config.Channel, msg)
+
+	if err != nats.ErrNoStreamResponse {
+		return err
+	}
+
+	if _, err = ensureJetStream(p.js, p.config, p.log); err != nil {
+		return err
+	}
+
+	_, err = p.js.Publish(p.config.Channel, msg)
+
+	return err
+}
+
+// ensureJetStream returns the broadcasts stream info (creating the stream if it doesn't exist)
+func ensureJetStream(js nats.JetStreamContext, c *NATSConfig, l *log.Entry) (*nats.StreamInfo, error) {
+	info, err := js.StreamInfo(c.JetStreamName)
+
+	if err == nil {
+		return info, nil
+	}
+
+	if err != nats.ErrStreamNotFound {
+		return nil, err
+	}
+
+	info, err = js.AddStream(&nats.StreamConfig{
+		Name:     c.JetStreamName,
+		Subjects: []string{c.Channel},
+		MaxMsgs:  c.JetStreamMaxMsgs,
+		Discard:  nats.DiscardOld,
+	})
+
+	if err != nil {
+		// The stream could be created by another node concurrently
+		if info, infoErr := js.StreamInfo(c.JetStreamName); infoErr == nil {
+			return info, nil
+		}
+
+		return nil, err
+	}
+
+	l.Infof("Created JetStream stream %s for subject %s", c.JetStreamName, c.Channel)
+
+	return info, nil
+}
@@ -0,0 +1,170 @@
+package pubsub
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/anycable/anycable-go/metrics"
+	natsserver "github.com/nats-io/nats-server/v2/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJetStreamSubscriber(t *testing.T) {
+	opts := natsserver.DefaultTestOptions
+	opts.Port = -1
+	opts.JetStream = true
+	opts.StoreDir = t.TempDir()
+
+	srv := natsserver.RunServer(&opts)
+	defer srv.Shutdown()
+
+	config := NewNATSConfig()
+	config.Servers = srv.ClientURL()
+
+	publisher := NewJetStreamPublisher(&config)
+	require.NoError(t, publisher.Start())
+	defer publisher.Shutdown() // nolint:errcheck
+
+	// Messages published before the subscriber started must be skipped
+	require.NoError(t, publisher.Publish([]byte("old")))
+
+	handler := &testHandler{messages: make(chan string, 10)}
+	subscriber := NewJetStreamSubscriber(handler, &config)
+
+	m := metrics.NewMetrics(nil, 10)
+	subscriber.SetMetrics(m)
+
+	done := make(chan error)
+	require.NoError(t, subscriber.Start(done))
+	defer subscriber.Shutdown() // nolint:errcheck
+
+	require.NoError(t, publisher.Publish([]byte("first")))
+	assert.Equal(t, "first", handler.Receive(t))
+
+	// Emulate connection loss: messages published while the consumer is gone
+	// must be delivered after re-subscribing
+	require.NoError(t, subscriber.sub.Unsubscribe())
+
+	require.NoError(t, publisher.Publish([]byte("second")))
+	require.NoError(t, publisher.Publish([]byte("third")))
+
+	require.NoError(t, subscriber.subscribe())
+
+	assert.Equal(t, "second", handler.Receive(t))
+	assert.Equal(t, "third", handler.Receive(t))
+
+	// Re-subscribing must not lead to duplicates
+	require.NoError(t, subscriber.subscribe())
+	require.NoError(t, publisher.Publish([]byte("fourth")))
+
+	assert.Equal(t, "fourth", handler.Receive(t))
+
+	select {
+	case msg := <-handler.messages:
+		t.Fatalf("Unexpected message received: %s", msg)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	assert.Equal(t, uint64(0), m.Gauge(metricsJetStreamLag).Value())
+}
+
+func TestJetStreamSubscriberServerRestart(t *testing.T) {
+	opts := natsserver.DefaultTestOptions
+	opts.Port = freeTCPPort(t)
+	opts.JetStream = true
+	opts.StoreDir = t.TempDir()
+
+	srv := natsserver.RunServer(&opts)
+
+	config := NewNATSConfig()
+	config.Servers = srv.ClientURL()
+
+	publisher := NewJetStreamPublisher(&config)
+	require.NoError(t, publisher.Start())
+	defer publisher.Shutdown() // nolint:errcheck
+
+	handler := &testHandler{messages: make(chan string, 10)}
+	subscriber := NewJetStreamSubscriber(handler, &config)
+
+	require.NoError(t, subscriber.Start(make(chan error, 1)))
+	defer subscriber.Shutdown() // nolint:errcheck
+
+	// Reconnect attempts must be unlimited
+	assert.Equal(t, -1, subscriber.conn.Opts.MaxReconnect)
+
+	require.NoError(t, publisher.Publish([]byte("first")))
+	assert.Equal(t, "first", handler.Receive(t))
+
+	srv.Shutdown()
+	srv.WaitForShutdown()
+
+	require.Eventually(t, func() bool { return !subscriber.conn.IsConnected() }, 5*time.Second, 10*time.Millisecond)
+
+	srv = natsserver.RunServer(&opts)
+	defer srv.Shutdown()
+
+	require.Eventually(t, func() bool { return subscriber.conn.IsConnected() && publisher.conn.IsConnected() }, 10*time.Second, 50*time.Millisecond)
+
+	// Publishing could fail until JetStream is recovered
+	require.Eventually(t, func() bool { return publisher.Publish([]byte("second")) == nil }, 10*time.Second, 100*time.Millisecond)
+
+	select {
+	case msg := <-handler.messages:
+		assert.Equal(t, "second", msg)
+	case <-time.After(10 * time.Second):
+		t.Fatal("Timed out to receive message after server restart")
+	}
+}
+
+func freeTCPPort(t *testing.T) int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func TestJetStreamSubscriberBindsExistingStream(t *testing.T) {
+	opts := natsserver.DefaultTestOptions
+	opts.Port = -1
+	opts.JetStream = true
+	opts.StoreDir = t.TempDir()
+
+	srv := natsserver.RunServer(&opts)
+	defer srv.Shutdown()
+
+	config := NewNATSConfig()
+	config.Servers = srv.ClientURL()
+	config.JetStreamMaxMsgs = 2
+
+	publisher := NewJetStreamPublisher(&config)
+	require.NoError(t, publisher.Start())
+	defer publisher.Shutdown() // nolint:errcheck
+
+	for _, msg := range []string{"a", "b", "c"} {
+		require.NoError(t, publisher.Publish([]byte(msg)))
+	}
+
+	info, err := publisher.js.StreamInfo(config.JetStreamName)
+	require.NoError(t, err)
+
+	assert.Equal(t, uint64(2), info.State.Msgs)
+	assert.Equal(t, uint64(3), info.State.LastSeq)
+
+	// Configuration changes don't affect the existing stream
+	config.JetStreamMaxMsgs = 100
+
+	handler := &testHandler{messages: make(chan string, 10)}
+	subscriber := NewJetStreamSubscriber(handler, &config)
+
+	require.NoError(t, subscriber.Start(make(chan error)))
+	defer subscriber.Shutdown() // nolint:errcheck
+
+	assert.Equal(t, uint64(3), subscriber.lastSeq)
+
+	info, err = subscriber.js.StreamInfo(config.JetStreamName)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), info.Config.MaxMsgs)
+}
@@ -0,0 +1,283 @@
+package pubsub
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/anycable/anycable-go/metrics"
+	"github.com/apex/log"
+	nanoid "github.com/matoous/go-nanoid"
+)
+
+// MultiSubscriber runs multiple subscribers at the same time (e.g., during migration from one adapter to another)
+type MultiSubscriber struct {
+	subscribers []Subscriber
+}
+
+var _ Subscriber = (*MultiSubscriber)(nil)
+var _ Instrumentable = (*MultiSubscriber)(nil)
+var _ StreamsSubscriber = (*MultiSubscriber)(nil)
+var _ StatefulSubscriber = (*MultiSubscriber)(nil)
+
+// NewMultiSubscriber returns new MultiSubscriber struct
+func NewMultiSubscriber(subscribers ...Subscriber) *MultiSubscriber {
+	return &MultiSubscriber{subscribers: subscribers}
+}
+
+// Start starts all the subscribers (stopping the already started ones if any fails)
+func (s *MultiSubscriber) Start(done chan (error)) error {
+	for i, subscriber := range s.subscribers {
+		if err := subscriber.Start(done); err != nil {
+			for _, started := range s.subscribers[:i] {
+				started.Shutdown() // nolint:errcheck
+			}
+
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Shutdown stops all the subscribers and returns the first error (if any)
+func (s *MultiSubscriber) Shutdown() error {
+	var res error
+
+	for _, subscriber := range s.subscribers {
+		if err := subscriber.Shutdown(); err != nil && res == nil {
+			res = err
+		}
+	}
+
+	return res
+}
+
+// SetMetrics passes the instrumenter to the subscribers reporting their own metrics
+func (s *MultiSubscriber) SetMetrics(m metrics.Instrumenter) {
+	for _, subscriber := range s.subscribers {
+		if instrumented, ok := subscriber.(Instrumentable); ok {
+			instrumented.SetMetrics(m)
+		}
+	}
+}
+
+// SubscribeStream notifies the subscribers using stream-specific channels
+func (s *MultiSubscriber) SubscribeStream(stream string) {
+	for _, subscriber := range s.subscribers {
+		if streamsSubscriber, ok := subscriber.(StreamsSubscriber); ok {
+			streamsSubscriber.SubscribeStream(stream)
+		}
+	}
+}
+
+// UnsubscribeStream notifies the subscribers using stream-specific channels
+func (s *MultiSubscriber) UnsubscribeStream(stream string) {
+	for _, subscriber := range s.subscribers {
+		if streamsSubscriber, ok := subscriber.(StreamsSubscriber); ok {
+			streamsSubscriber.UnsubscribeStream(stream)
+		}
+	}
+}
+
+// Ready returns true if all the subscribers tracking their state are ready
+func (s *MultiSubscriber) Ready() bool {
+	for _, subscriber := range s.subscribers {
+		if stateful, ok := subscriber.(StatefulSubscriber); ok && !stateful.Ready() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// OnStateChange registers a callback to be called when the combined state of subscribers changes
+func (s *MultiSubscriber) OnStateChange(fn func(ready bool)) {
+	var mu sync.Mutex
+	ready := s.Ready()
+
+	for _, subscriber := range s.subscribers {
+		if stateful, ok := subscriber.(StatefulSubscriber); ok {
+			stateful.OnStateChange(func(bool) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				if current := s.Ready(); current != ready {
+					ready = current
+					fn(current)
+				}
+			})
+		}
+	}
+}
+
+// MultiPublisher publishes messages via all the publishers (so nodes consuming any of the adapters receive them).
+// Messages are stamped with IDs (unless they already have ones), so DedupHandler could skip the copies.
+type MultiPublisher struct {
+	publishers []Publisher
+}
+
+var _ Publisher = (*MultiPublisher)(nil)
+
+// NewMultiPublisher returns new MultiPublisher struct
+func NewMultiPublisher(publishers ...Publisher) *MultiPublisher {
+	return &MultiPublisher{publishers: publishers}
+}
+
+// Start starts all the publishers (stopping the already started ones if any fails)
+func (p *MultiPublisher) Start() error {
+	for i, publisher := range p.publishers {
+		if err := publisher.Start(); err != nil {
+			for _, started := range p.publishers[:i] {
+				started.Shutdown() // nolint:errcheck
+			}
+
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Publish sends the message via all the publishers and returns the first error (if any)
+func (p *MultiPublisher) Publish(msg []byte) error {
+	msg = withMessageID(msg)
+
+	var res error
+
+	for _, publisher := range p.publishers {
+		if err := publisher.Publish(msg); err != nil && res == nil {
+			res = err
+		}
+	}
+
+	return res
+}
+
+// Shutdown stops all the publishers and returns the first error (if any)
+func (p *MultiPublisher) Shutdown() error {
+	var res error
+
+	for _, publisher := range p.publishers {
+		if err := publisher.Shutdown(); err != nil && res == nil {
+			res = err
+		}
+	}
+
+	return res
+}
+
+// DedupHandler skips messages with the recently seen IDs (the top-level "id" field),
+// so messages published via multiple adapters are delivered once.
+// Messages without IDs are passed as is.
+type DedupHandler struct {
+	handler Handler
+
+	// Recently seen IDs (ring contains them in the order of arrival to evict the oldest one)
+	ids  map[string]struct{}
+	ring []string
+	pos  int
+	mu   sync.Mutex
+
+	log *log.Entry
+}
+
+var _ Handler = (*DedupHandler)(nil)
+
+// NewDedupHandler returns new DedupHandler struct remembering up to size recent IDs
+func NewDedupHandler(handler Handler, size int) *DedupHandler {
+	return &DedupHandler{
+		handler: handler,
+		ids:     make(map[string]struct{}, size),
+		ring:    make([]string, size),
+		log:     log.WithFields(log.Fields{"context": "pubsub"}),
+	}
+}
+
+// HandlePubSub passes the message to the underlying handler unless it's a duplicate
+func (h *DedupHandler) HandlePubSub(msg []byte) {
+	if id := messageID(msg); id != "" && !h.remember(id) {
+		h.log.Debugf("Skip duplicate pubsub message: %s", id)
+		return
+	}
+
+	h.handler.HandlePubSub(msg)
+}
+
+// remember adds the ID to the recently seen list and returns false if it's already there
+func (h *DedupHandler) remember(id string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.ids[id]; ok {
+		return false
+	}
+
+	if evicted := h.ring[h.pos]; evicted != "" {
+		delete(h.ids, evicted)
+	}
+
+	h.ring[h.pos] = id
+	h.ids[id] = struct{}{}
+	h.pos = (h.pos + 1) % len(h.ring)
+
+	return true
+}
+
+// messageID returns the raw value of the top-level "id" field (could be a string or a number)
+func messageID(msg []byte) string {
+	var payload struct {
+		ID json.RawMessage `json:"id"`
+	}
+
+	if err := json.Unmarshal(msg, &payload); err != nil {
+		return ""
+	}
+
+	if id := string(payload.ID); id != "null" {
+		return id
+	}
+
+	return ""
+}
+
+// withMessageID adds the top-level "id" field with a random value to the JSON object
+// (messages already having IDs and non-object messages are returned as is)
+func withMessageID(msg []byte) []byte {
+	trimmed := bytes.TrimSpace(msg)
+
+	if len(trimmed) == 0 || trimmed[0] != '{' || messageID(msg) != "" {
+		return msg
+	}
+
+	id, err := nanoid.Nanoid()
+
+	if err != nil {
+		return msg
+	}
+
+	rest := bytes.TrimSpace(trimmed[1:])
+
+	res := make([]byte, 0, len(trimmed)+len(id)+8)
+	res = append(res, `{"id":"`...)
+	res = append(res, id...)
+	res = append(res, '"')
+
+	if len(rest) > 0 && rest[0] != '}' {
+		res = append(res, ',')
+	}
+
+	return append(res, rest...)
+}
+
+// splitAdapters returns the list of adapters from the comma-separated string
+func splitAdapters(adapter string) []string {
+	adapters := strings.Split(adapter, ",")
+
+	for i, name := range adapters {
+		adapters[i] = strings.TrimSpace(name)
+	}
+
+	return adapters
+}
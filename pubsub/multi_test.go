@@ -0,0 +1,229 @@
+package pubsub
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSubscriber struct {
+	startErr error
+	started  bool
+	stopped  bool
+	streams  []string
+}
+
+func (s *fakeSubscriber) Start(done chan (error)) error {
+	if s.startErr != nil {
+		return s.startErr
+	}
+
+	s.started = true
+	return nil
+}
+
+func (s *fakeSubscriber) Shutdown() error {
+	s.stopped = true
+	return nil
+}
+
+func (s *fakeSubscriber) SubscribeStream(stream string) {
+	s.streams = append(s.streams, stream)
+}
+
+func (s *fakeSubscriber) UnsubscribeStream(stream string) {}
+
+func TestMultiSubscriber(t *testing.T) {
+	a := &fakeSubscriber{}
+	b := &fakeSubscriber{}
+
+	subscriber := NewMultiSubscriber(a, b)
+
+	require.NoError(t, subscriber.Start(make(chan error)))
+	assert.True(t, a.started)
+	assert.True(t, b.started)
+
+	subscriber.SubscribeStream("chat_1")
+	assert.Equal(t, []string{"chat_1"}, a.streams)
+	assert.Equal(t, []string{"chat_1"}, b.streams)
+
+	require.NoError(t, subscriber.Shutdown())
+	assert.True(t, a.stopped)
+	assert.True(t, b.stopped)
+
+	t.Run("When one of subscribers fails to start", func(t *testing.T) {
+		a := &fakeSubscriber{}
+		b := &fakeSubscriber{startErr: errors.New("failed")}
+		c := &fakeSubscriber{}
+
+		subscriber := NewMultiSubscriber(a, b, c)
+
+		require.Error(t, subscriber.Start(make(chan error)))
+		assert.True(t, a.stopped)
+		assert.False(t, c.started)
+	})
+}
+
+func TestMultiSubscriberState(t *testing.T) {
+	redis := NewRedisConfig()
+
+	a := NewRedisSubscriber(nil, &redis)
+	b := NewRedisXSubscriber(nil, &redis)
+
+	subscriber := NewMultiSubscriber(a, b, &fakeSubscriber{})
+
+	states := []bool{}
+	subscriber.OnStateChange(func(ready bool) { states = append(states, ready) })
+
+	a.setConnected(true)
+	assert.False(t, subscriber.Ready())
+
+	b.setConnected(true)
+	assert.True(t, subscriber.Ready())
+
+	a.setConnected(false)
+	b.setConnected(false)
+	assert.False(t, subscriber.Ready())
+
+	a.setConnected(true)
+	b.setConnected(true)
+
+	assert.Equal(t, []bool{true, false, true}, states)
+}
+
+func TestNewMultiSubscriber(t *testing.T) {
+	redis := NewRedisConfig()
+	http := NewHTTPConfig()
+	nats := NewNATSConfig()
+
+	subscriber, err := NewSubscriber(nil, "redis, nats", &redis, &http, &nats)
+	require.NoError(t, err)
+
+	multi := subscriber.(*MultiSubscriber)
+	require.Len(t, multi.subscribers, 2)
+	assert.IsType(t, &RedisSubscriber{}, multi.subscribers[0])
+	assert.IsType(t, &NATSSubscriber{}, multi.subscribers[1])
+
+	_, err = NewSubscriber(nil, "redis,redis", &redis, &http, &nats)
+	assert.Error(t, err)
+
+	_, err = NewSubscriber(nil, "redis,kafka", &redis, &http, &nats)
+	assert.Error(t, err)
+
+	http.Bus = "redis"
+
+	_, err = NewSubscriber(nil, "http,redis", &redis, &http, &nats)
+	assert.Error(t, err)
+}
+
+func TestNewMultiPublisher(t *testing.T) {
+	redis := NewRedisConfig()
+	http := NewHTTPConfig()
+	nats := NewNATSConfig()
+
+	publisher, err := NewPublisher("http,nats,redis", &redis, &http, &nats)
+	require.NoError(t, err)
+	require.IsType(t, &MultiPublisher{}, publisher)
+	assert.Len(t, publisher.(*MultiPublisher).publishers, 2)
+
+	publisher, err = NewPublisher("http,nats", &redis, &http, &nats)
+	require.NoError(t, err)
+	assert.IsType(t, &NATSPublisher{}, publisher)
+
+	publisher, err = NewPublisher("http", &redis, &http, &nats)
+	require.NoError(t, err)
+	assert.IsType(t, &NoopPublisher{}, publisher)
+}
+
+func TestMultiPublisher(t *testing.T) {
+	first := &testPublisher{}
+	second := &testPublisher{}
+
+	publisher := NewMultiPublisher(first, second)
+
+	require.NoError(t, publisher.Start())
+	defer publisher.Shutdown() // nolint:errcheck
+
+	t.Run("Publishes messages with the same ID via all publishers", func(t *testing.T) {
+		require.NoError(t, publisher.Publish([]byte(`{"stream":"chat","data":"hi"}`)))
+
+		require.Len(t, first.messages, 1)
+		require.Len(t, second.messages, 1)
+
+		assert.Equal(t, first.messages[0], second.messages[0])
+		assert.NotEmpty(t, messageID(first.messages[0]))
+
+		var msg map[string]string
+		require.NoError(t, json.Unmarshal(first.messages[0], &msg))
+		assert.Equal(t, "chat", msg["stream"])
+		assert.Equal(t, "hi", msg["data"])
+	})
+
+	t.Run("Keeps existing IDs", func(t *testing.T) {
+		require.NoError(t, publisher.Publish([]byte(`{"id":42,"stream":"chat","data":"hi"}`)))
+
+		assert.Equal(t, `{"id":42,"stream":"chat","data":"hi"}`, string(second.messages[1]))
+	})
+
+	t.Run("Deduplicated by handler", func(t *testing.T) {
+		handler := &testHandler{messages: make(chan string, 10)}
+		dedup := NewDedupHandler(handler, 10)
+
+		dedup.HandlePubSub(first.messages[0])
+		dedup.HandlePubSub(second.messages[0])
+
+		assert.Len(t, handler.messages, 1)
+	})
+
+	t.Run("Returns the first error", func(t *testing.T) {
+		first.err = errors.New("failed")
+		defer func() { first.err = nil }()
+
+		assert.Error(t, publisher.Publish([]byte(`{"stream":"chat","data":"hi"}`)))
+		assert.Len(t, second.messages, 3)
+	})
+}
+
+func TestWithMessageID(t *testing.T) {
+	assert.Equal(t, `{"id":1}`, string(withMessageID([]byte(`{"id":1}`))))
+	assert.Equal(t, `"hi"`, string(withMessageID([]byte(`"hi"`))))
+
+	msg := withMessageID([]byte(` { } `))
+	assert.NotEmpty(t, messageID(msg))
+	assert.True(t, json.Valid(msg))
+}
+
+func TestDedupHandler(t *testing.T) {
+	handler := &testHandler{messages: make(chan string, 10)}
+	dedup := NewDedupHandler(handler, 2)
+
+	dedup.HandlePubSub([]byte(`{"id":"1","stream":"chat","data":"a"}`))
+	dedup.HandlePubSub([]byte(`{"id":"1","stream":"chat","data":"a"}`))
+	dedup.HandlePubSub([]byte(`{"id":2,"stream":"chat","data":"b"}`))
+	dedup.HandlePubSub([]byte(`{"stream":"chat","data":"c"}`))
+	dedup.HandlePubSub([]byte(`{"stream":"chat","data":"c"}`))
+	dedup.HandlePubSub([]byte(`{"id":"3","stream":"chat","data":"d"}`))
+	// The oldest ID has been evicted
+	dedup.HandlePubSub([]byte(`{"id":"1","stream":"chat","data":"a"}`))
+	dedup.HandlePubSub([]byte(`{"id":"3","stream":"chat","data":"d"}`))
+
+	close(handler.messages)
+
+	received := []string{}
+
+	for msg := range handler.messages {
+		received = append(received, msg)
+	}
+
+	assert.Equal(t, []string{
+		`{"id":"1","stream":"chat","data":"a"}`,
+		`{"id":2,"stream":"chat","data":"b"}`,
+		`{"stream":"chat","data":"c"}`,
+		`{"stream":"chat","data":"c"}`,
+		`{"id":"3","stream":"chat","data":"d"}`,
+		`{"id":"1","stream":"chat","data":"a"}`,
+	}, received)
+}
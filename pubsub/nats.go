@@ -1,35 +1,71 @@
 package pubsub
 
 import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
 	"github.com/apex/log"
 	"github.com/nats-io/nats.go"
 )
 
+// How often to retry failed stream subjects subscriptions
+const natsResubscribeInterval = time.Second
+
 type NATSSubscriber struct {
 	conn    *nats.Conn
 	handler Handler
 	config  *NATSConfig
 
+	// Streams with local subscribers (stream subjects subscriptions are synced asynchronously)
+	streams   map[string]bool
+	streamsMu sync.Mutex
+	// Notifies the sync goroutine to update stream subjects subscriptions
+	syncCh chan struct{}
+	// Active stream subjects subscriptions
+	subscriptions   map[string]*nats.Subscription
+	subscriptionsMu sync.Mutex
+
+	shutdownCh   chan struct{}
+	shutdownOnce sync.Once
+
 	log *log.Entry
 }
 
 var _ Subscriber = (*NATSSubscriber)(nil)
+var _ StreamsSubscriber = (*NATSSubscriber)(nil)
 
 type NATSConfig struct {
 	Servers              string
 	Channel              string
 	DontRandomizeServers bool
+	// Whether to use a separate subject per stream (subscribing only to streams with local subscribers)
+	StreamSubjects bool
+	// JetStream stream name (jetstream adapter)
+	JetStreamName string
+	// The max number of messages to keep in the JetStream stream (jetstream adapter)
+	JetStreamMaxMsgs int64
 }
 
 func NewNATSConfig() NATSConfig {
-	return NATSConfig{Servers: nats.DefaultURL, Channel: "__anycable__"}
+	return NATSConfig{
+		Servers:          nats.DefaultURL,
+		Channel:          "__anycable__",
+		JetStreamName:    defaultJetStreamName,
+		JetStreamMaxMsgs: defaultJetStreamMaxMsgs,
+	}
 }
 
 func NewNATSSubscriber(node Handler, c *NATSConfig) *NATSSubscriber {
 	return &NATSSubscriber{
-		config:  c,
-		handler: node,
-		log:     log.WithFields(log.Fields{"context": "pubsub", "provider": "nats"}),
+		config:        c,
+		handler:       node,
+		streams:       make(map[string]bool),
+		syncCh:        make(chan struct{}, 1),
+		subscriptions: make(map[string]*nats.Subscription),
+		shutdownCh:    make(chan struct{}),
+		log:           log.WithFields(log.Fields{"context": "pubsub", "provider": "nats"}),
 	}
 }
 
@@ -57,10 +93,7 @@ func (s *NATSSubscriber) Start(done chan (error)) error {
 		return err
 	}
 
-	_, err = nc.Subscribe(s.config.Channel, func(m *nats.Msg) {
-		s.log.Debugf("Incoming pubsub message: %s", m.Data)
-		s.handler.HandlePubSub(m.Data)
-	})
+	_, err = nc.Subscribe(s.config.Channel, s.handleMessage)
 
 	if err != nil {
 		nc.Close()
@@ -71,13 +104,215 @@ func (s *NATSSubscriber) Start(done chan (error)) error {
 
 	s.conn = nc
 
+	if s.config.StreamSubjects {
+		// Subscriptions requested before start are made by the sync goroutine
+		go s.runSync()
+		s.requestSync()
+	}
+
 	return nil
 }
 
 func (s *NATSSubscriber) Shutdown() error {
+	s.shutdownOnce.Do(func() { close(s.shutdownCh) })
+
 	if s.conn != nil {
 		s.conn.Close()
 	}
 
 	return nil
 }
+
+// SubscribeStream adds the stream to the list of streams to receive broadcasts for (when using stream subjects).
+// Subscriptions are made asynchronously (so the call never blocks);
+// NATS client re-subscribes automatically after reconnecting.
+func (s *NATSSubscriber) SubscribeStream(stream string) {
+	if !s.config.StreamSubjects {
+		return
+	}
+
+	s.streamsMu.Lock()
+	s.streams[stream] = true
+	s.streamsMu.Unlock()
+
+	s.requestSync()
+}
+
+// UnsubscribeStream removes the stream from the list of streams to receive broadcasts for
+func (s *NATSSubscriber) UnsubscribeStream(stream string) {
+	if !s.config.StreamSubjects {
+		return
+	}
+
+	s.streamsMu.Lock()
+	delete(s.streams, stream)
+	s.streamsMu.Unlock()
+
+	s.requestSync()
+}
+
+func (s *NATSSubscriber) requestSync() {
+	select {
+	case s.syncCh <- struct{}{}:
+	default:
+	}
+}
+
+// runSync updates stream subjects subscriptions when requested until shutdown
+// (failed subscriptions are retried periodically)
+func (s *NATSSubscriber) runSync() {
+	var retry <-chan time.Time
+
+	for {
+		select {
+		case <-s.syncCh:
+		case <-retry:
+		case <-s.shutdownCh:
+			return
+		}
+
+		retry = nil
+
+		if !s.syncStreams() {
+			retry = time.After(natsResubscribeInterval)
+		}
+	}
+}
+
+// syncStreams subscribes to the subjects of the streams with local subscribers
+// and unsubscribes from the subjects of the streams without them.
+// Returns false if some subscriptions failed.
+func (s *NATSSubscriber) syncStreams() bool {
+	s.streamsMu.Lock()
+	streams := make(map[string]bool, len(s.streams))
+
+	for stream := range s.streams {
+		streams[stream] = true
+	}
+	s.streamsMu.Unlock()
+
+	s.subscriptionsMu.Lock()
+	defer s.subscriptionsMu.Unlock()
+
+	for stream, sub := range s.subscriptions {
+		if streams[stream] {
+			continue
+		}
+
+		delete(s.subscriptions, stream)
+
+		if err := sub.Unsubscribe(); err != nil {
+			s.log.Warnf("Failed to unsubscribe from stream %s: %v", stream, err)
+		}
+	}
+
+	ok := true
+
+	for stream := range streams {
+		if _, subscribed := s.subscriptions[stream]; subscribed {
+			continue
+		}
+
+		sub, err := s.conn.Subscribe(streamSubject(s.config.Channel, stream), s.handleMessage)
+
+		if err != nil {
+			s.log.Errorf("Failed to subscribe to stream %s: %v", stream, err)
+			ok = false
+			continue
+		}
+
+		s.subscriptions[stream] = sub
+	}
+
+	return ok
+}
+
+func (s *NATSSubscriber) handleMessage(m *nats.Msg) {
+	s.log.Debugf("Incoming pubsub message: %s", m.Data)
+	s.handler.HandlePubSub(m.Data)
+}
+
+// NATSPublisher publishes messages to the NATS broadcast channel
+type NATSPublisher struct {
+	conn   *nats.Conn
+	config *NATSConfig
+
+	log *log.Entry
+}
+
+var _ Publisher = (*NATSPublisher)(nil)
+
+// NewNATSPublisher builds a new NATSPublisher struct
+func NewNATSPublisher(c *NATSConfig) *NATSPublisher {
+	return &NATSPublisher{
+		config: c,
+		log:    log.WithFields(log.Fields{"context": "pubsub", "provider": "nats"}),
+	}
+}
+
+func (p *NATSPublisher) Start() error {
+	connectOptions := []nats.Option{
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+	}
+
+	if p.config.DontRandomizeServers {
+		connectOptions = append(connectOptions, nats.DontRandomize())
+	}
+
+	nc, err := nats.Connect(p.config.Servers, connectOptions...)
+
+	if err != nil {
+		return err
+	}
+
+	p.conn = nc
+
+	return nil
+}
+
+// Publish sends the message to the broadcast channel
+// (or to the stream subject for broadcasts when using stream subjects)
+func (p *NATSPublisher) Publish(msg []byte) error {
+	subject := p.config.Channel
+
+	if p.config.StreamSubjects {
+		if stream := broadcastStream(msg); stream != "" {
+			subject = streamSubject(p.config.Channel, stream)
+		}
+	}
+
+	return p.conn.Publish(subject, msg)
+}
+
+func (p *NATSPublisher) Shutdown() error {
+	if p.conn != nil {
+		p.conn.Close()
+	}
+
+	return nil
+}
+
+// streamSubject returns the stream-specific subject (e.g., "__anycable__.stream.chat_42")
+func streamSubject(channel string, stream string) string {
+	return channel + ".stream." + EncodeSubjectToken(stream)
+}
+
+// EncodeSubjectToken makes the string a valid NATS subject token:
+// alphanumeric characters, "-" and "_" are kept as is, other bytes (including "%") are percent-encoded
+// (e.g., "chat:42" -> "chat%3A42")
+func EncodeSubjectToken(str string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(str); i++ {
+		c := str[i]
+
+		if ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') || c == '-' || c == '_' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,146 @@
+package pubsub
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	natsserver "github.com/nats-io/nats-server/v2/test"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNATSStreamSubjects(t *testing.T) {
+	opts := natsserver.DefaultTestOptions
+	opts.Port = -1
+
+	srv := natsserver.RunServer(&opts)
+	defer srv.Shutdown()
+
+	config := NewNATSConfig()
+	config.Servers = srv.ClientURL()
+	config.StreamSubjects = true
+
+	handler := &testHandler{messages: make(chan string, 10)}
+	subscriber := NewNATSSubscriber(handler, &config)
+
+	// Subscriptions made before start must be applied on start
+	subscriber.SubscribeStream("chat:1")
+
+	done := make(chan error)
+	require.NoError(t, subscriber.Start(done))
+	defer subscriber.Shutdown() // nolint:errcheck
+
+	publisher := NewNATSPublisher(&config)
+	require.NoError(t, publisher.Start())
+	defer publisher.Shutdown() // nolint:errcheck
+
+	subscriber.SubscribeStream("chat_2")
+
+	// Subscriptions are made asynchronously
+	waitNATSSubscriptions(t, subscriber, "chat:1", "chat_2")
+
+	// Make sure subscriptions are processed by the server
+	require.NoError(t, subscriber.conn.Flush())
+
+	publish := func(msg string) {
+		require.NoError(t, publisher.Publish([]byte(msg)))
+		require.NoError(t, publisher.conn.Flush())
+	}
+
+	publish(`{"stream":"chat:1","data":"1"}`)
+	assert.Equal(t, `{"stream":"chat:1","data":"1"}`, handler.Receive(t))
+
+	publish(`{"stream":"chat_2","data":"2"}`)
+	assert.Equal(t, `{"stream":"chat_2","data":"2"}`, handler.Receive(t))
+
+	// Commands are published to the common channel
+	publish(`{"command":"disconnect","payload":{"identifier":"42"}}`)
+	assert.Equal(t, `{"command":"disconnect","payload":{"identifier":"42"}}`, handler.Receive(t))
+
+	subscriber.UnsubscribeStream("chat:1")
+	waitNATSSubscriptions(t, subscriber, "chat_2")
+	require.NoError(t, subscriber.conn.Flush())
+
+	publish(`{"stream":"chat:1","data":"3"}`)
+	publish(`{"stream":"chat_3","data":"4"}`)
+
+	select {
+	case msg := <-handler.messages:
+		t.Fatalf("Unexpected message received: %s", msg)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestNATSStreamSubjectsRetry(t *testing.T) {
+	opts := natsserver.DefaultTestOptions
+	opts.Port = -1
+
+	srv := natsserver.RunServer(&opts)
+	defer srv.Shutdown()
+
+	config := NewNATSConfig()
+	config.Servers = srv.ClientURL()
+	config.StreamSubjects = true
+
+	subscriber := NewNATSSubscriber(&testHandler{messages: make(chan string, 10)}, &config)
+	subscriber.streams["chat_1"] = true
+
+	conn, err := nats.Connect(srv.ClientURL())
+	require.NoError(t, err)
+
+	// Subscriptions fail for closed connections
+	subscriber.conn = conn
+	conn.Close()
+
+	assert.False(t, subscriber.syncStreams())
+	assert.Empty(t, subscriber.subscriptions)
+
+	conn, err = nats.Connect(srv.ClientURL())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	subscriber.conn = conn
+
+	assert.True(t, subscriber.syncStreams())
+	assert.Contains(t, subscriber.subscriptions, "chat_1")
+}
+
+func TestNATSStreamSubjectsDisabled(t *testing.T) {
+	config := NewNATSConfig()
+
+	subscriber := NewNATSSubscriber(nil, &config)
+	subscriber.SubscribeStream("chat_1")
+
+	assert.Empty(t, subscriber.streams)
+}
+
+func waitNATSSubscriptions(t *testing.T, subscriber *NATSSubscriber, streams ...string) {
+	require.Eventually(t, func() bool {
+		subscriber.subscriptionsMu.Lock()
+		defer subscriber.subscriptionsMu.Unlock()
+
+		if len(subscriber.subscriptions) != len(streams) {
+			return false
+		}
+
+		for _, stream := range streams {
+			if _, ok := subscriber.subscriptions[stream]; !ok {
+				return false
+			}
+		}
+
+		return true
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestEncodeSubjectToken(t *testing.T) {
+	assert.Equal(t, "chat_42", EncodeSubjectToken("chat_42"))
+	assert.Equal(t, "chat%3A42", EncodeSubjectToken("chat:42"))
+	assert.Equal(t, "a%2Eb%2A%3E%20%25", EncodeSubjectToken("a.b*> %"))
+	assert.Equal(t, "%D0%BF", EncodeSubjectToken("п"))
+
+	// Encoded tokens must be valid subjects
+	assert.True(t, server.IsValidLiteralSubject("__anycable__.stream."+EncodeSubjectToken("a.b* >\tc")))
+}
@@ -0,0 +1,231 @@
+package pubsub
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/anycable/anycable-go/metrics"
+	"github.com/apex/log"
+)
+
+const (
+	// The max number of messages waiting to be published by AsyncPublisher
+	defaultPublishQueueSize = 4096
+
+	metricsPublishDropped = "publish_dropped_total"
+)
+
+// Publisher is responsible for sending messages to other nodes
+// (e.g., broadcasts originated by the node or presence state)
+type Publisher interface {
+	Start() error
+	Publish(msg []byte) error
+	Shutdown() error
+}
+
+// NoopPublisher is used when the adapter doesn't support publishing (e.g., HTTP)
+type NoopPublisher struct{}
+
+var _ Publisher = (*NoopPublisher)(nil)
+
+// Start does nothing
+func (NoopPublisher) Start() error {
+	return nil
+}
+
+// Publish does nothing
+func (NoopPublisher) Publish(msg []byte) error {
+	return nil
+}
+
+// Shutdown does nothing
+func (NoopPublisher) Shutdown() error {
+	return nil
+}
+
+// AsyncPublisher publishes messages from a bounded queue in the background,
+// so callers (e.g., sessions performing actions) are not blocked by a slow or unresponsive broker.
+// Messages are dropped when the queue is full.
+type AsyncPublisher struct {
+	publisher Publisher
+	queue     chan []byte
+	done      chan struct{}
+
+	started bool
+	closed  bool
+	mu      sync.RWMutex
+
+	metrics metrics.Instrumenter
+	log     *log.Entry
+}
+
+var _ Publisher = (*AsyncPublisher)(nil)
+var _ Instrumentable = (*AsyncPublisher)(nil)
+
+// NewAsyncPublisher returns new AsyncPublisher struct with the specified queue size
+// (the default size is used if it's not positive)
+func NewAsyncPublisher(publisher Publisher, size int) *AsyncPublisher {
+	if size <= 0 {
+		size = defaultPublishQueueSize
+	}
+
+	return &AsyncPublisher{
+		publisher: publisher,
+		queue:     make(chan []byte, size),
+		done:      make(chan struct{}),
+		log:       log.WithFields(log.Fields{"context": "pubsub"}),
+	}
+}
+
+// SetMetrics registers the dropped messages counter (and passes the instrumenter to the underlying publisher)
+func (p *AsyncPublisher) SetMetrics(m metrics.Instrumenter) {
+	p.metrics = m
+	p.metrics.RegisterCounter(metricsPublishDropped, "The total number of messages originated by the node and dropped due to the full publish queue")
+
+	if instrumented, ok := p.publisher.(Instrumentable); ok {
+		instrumented.SetMetrics(m)
+	}
+}
+
+// Start starts the underlying publisher and the publishing goroutine
+func (p *AsyncPublisher) Start() error {
+	if err := p.publisher.Start(); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	p.started = true
+	p.mu.Unlock()
+
+	go p.run()
+
+	return nil
+}
+
+// Publish adds the message to the queue; returns error if the queue is full (the message is dropped)
+func (p *AsyncPublisher) Publish(msg []byte) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return errors.New("Publisher is closed")
+	}
+
+	select {
+	case p.queue <- msg:
+		return nil
+	default:
+	}
+
+	if p.metrics != nil {
+		p.metrics.CounterIncrement(metricsPublishDropped)
+	}
+
+	return errors.New("Publish queue is full, message dropped")
+}
+
+// Shutdown publishes the pending messages and stops the underlying publisher
+func (p *AsyncPublisher) Shutdown() error {
+	p.mu.Lock()
+
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+
+	p.closed = true
+	close(p.queue)
+	started := p.started
+	p.mu.Unlock()
+
+	if started {
+		<-p.done
+	}
+
+	return p.publisher.Shutdown()
+}
+
+func (p *AsyncPublisher) run() {
+	defer close(p.done)
+
+	for msg := range p.queue {
+		if err := p.publisher.Publish(msg); err != nil {
+			p.log.Warnf("Failed to publish message: %v", err)
+		}
+	}
+}
+
+// NewPublisher creates a publisher for the provided adapter
+// (HTTP adapter publishes to the bus if configured).
+// If multiple adapters are provided, messages are published via all the adapters supporting publishing.
+func NewPublisher(adapter string, redis *RedisConfig, http *HTTPConfig, nats *NATSConfig) (Publisher, error) {
+	if adapters := splitAdapters(adapter); len(adapters) > 1 {
+		publishers := []Publisher{}
+
+		for _, name := range adapters {
+			if name == "http" && http.Bus == "" {
+				continue
+			}
+
+			publisher, err := NewPublisher(name, redis, http, nats)
+
+			if err != nil {
+				return nil, err
+			}
+
+			publishers = append(publishers, publisher)
+		}
+
+		switch len(publishers) {
+		case 0:
+			return &NoopPublisher{}, nil
+		case 1:
+			return publishers[0], nil
+		}
+
+		return NewMultiPublisher(publishers...), nil
+	}
+
+	switch adapter {
+	case "redis":
+		return NewRedisPublisher(redis), nil
+	case "redisx":
+		return NewRedisXPublisher(redis), nil
+	case "http":
+		if http.Bus == "" {
+			return &NoopPublisher{}, nil
+		}
+
+		if !IsBusAdapter(http.Bus) {
+			return nil, fmt.Errorf("Unsupported HTTP broadcast bus: %s", http.Bus)
+		}
+
+		return NewPublisher(http.Bus, redis, http, nats)
+	case "nats":
+		return NewNATSPublisher(nats), nil
+	case "jetstream":
+		return NewJetStreamPublisher(nats), nil
+	}
+
+	return nil, fmt.Errorf("Unknown adapter type: %s", adapter)
+}
+
+// IsBusAdapter returns true if the adapter could be used as a bus to deliver messages to all nodes
+func IsBusAdapter(adapter string) bool {
+	return adapter == "redis" || adapter == "redisx" || adapter == "nats" || adapter == "jetstream"
+}
+
+// broadcastStream returns the stream name if the message is a broadcast (and an empty string otherwise)
+func broadcastStream(msg []byte) string {
+	var broadcast struct {
+		Stream string `json:"stream"`
+	}
+
+	if err := json.Unmarshal(msg, &broadcast); err != nil {
+		return ""
+	}
+
+	return broadcast.Stream
+}
@@ -0,0 +1,60 @@
+package pubsub
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anycable/anycable-go/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingPublisher waits for the release signal before publishing each message
+type blockingPublisher struct {
+	testPublisher
+	release chan struct{}
+	stopped bool
+}
+
+func (p *blockingPublisher) Publish(msg []byte) error {
+	<-p.release
+	return p.testPublisher.Publish(msg)
+}
+
+func (p *blockingPublisher) Shutdown() error {
+	p.stopped = true
+	return nil
+}
+
+func TestAsyncPublisher(t *testing.T) {
+	target := &blockingPublisher{release: make(chan struct{})}
+	m := metrics.NewMetrics(nil, 10)
+
+	publisher := NewAsyncPublisher(target, 2)
+	publisher.SetMetrics(m)
+
+	require.NoError(t, publisher.Start())
+
+	// The first message is being published (and blocked), two more fill the queue
+	require.NoError(t, publisher.Publish([]byte("1")))
+	require.Eventually(t, func() bool { return len(publisher.queue) == 0 }, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, publisher.Publish([]byte("2")))
+	require.NoError(t, publisher.Publish([]byte("3")))
+
+	start := time.Now()
+
+	assert.Error(t, publisher.Publish([]byte("4")), "Message must be dropped when the queue is full")
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+	assert.Equal(t, uint64(1), m.Counter(metricsPublishDropped).Value())
+
+	close(target.release)
+
+	// Pending messages are published on shutdown
+	require.NoError(t, publisher.Shutdown())
+
+	assert.Equal(t, [][]byte{[]byte("1"), []byte("2"), []byte("3")}, target.messages)
+	assert.True(t, target.stopped)
+
+	assert.Error(t, publisher.Publish([]byte("5")))
+}
@@ -0,0 +1,245 @@
+package pubsub
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+const redisClusterSlots = 16384
+
+// redisCluster keeps track of Redis Cluster nodes serving hash slots,
+// so commands for a particular key (or sharded channel) are sent to the right node
+type redisCluster struct {
+	// Addresses of the nodes to discover the cluster from
+	nodes []string
+	// URL to connect to nodes (the host is replaced with the node address)
+	uri     url.URL
+	options []redis.DialOption
+
+	// Addresses of the nodes serving the slots (empty if unknown)
+	owners []string
+	mu     sync.RWMutex
+}
+
+func newRedisCluster(nodes string, uri *url.URL, options ...redis.DialOption) *redisCluster {
+	addrs := []string{}
+
+	for _, addr := range strings.Split(nodes, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			addrs = append(addrs, addr)
+		}
+	}
+
+	nodeURI := *uri
+	// Redis Cluster only supports the database 0
+	nodeURI.Path = ""
+
+	return &redisCluster{
+		nodes:   addrs,
+		uri:     nodeURI,
+		options: options,
+		owners:  make([]string, redisClusterSlots),
+	}
+}
+
+// nodeURL returns the URL to connect to the node
+func (c *redisCluster) nodeURL(addr string) string {
+	uri := c.uri
+	uri.Host = addr
+
+	return uri.String()
+}
+
+// dial connects to the node
+func (c *redisCluster) dial(addr string) (redis.Conn, error) {
+	return redis.DialURL(c.nodeURL(addr), c.options...)
+}
+
+// seed returns the address of the configured node to use for the attempt (rotating them on failures)
+func (c *redisCluster) seed(attempt int) string {
+	return c.nodes[attempt%len(c.nodes)]
+}
+
+// refresh fetches the slots distribution from the first available configured node
+func (c *redisCluster) refresh() error {
+	var err error
+
+	for _, addr := range c.nodes {
+		var owners []string
+
+		if owners, err = c.fetchSlots(addr); err == nil {
+			c.mu.Lock()
+			c.owners = owners
+			c.mu.Unlock()
+
+			return nil
+		}
+	}
+
+	return fmt.Errorf("Failed to fetch Redis Cluster slots: %v", err)
+}
+
+func (c *redisCluster) fetchSlots(addr string) ([]string, error) {
+	conn, err := c.dial(addr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer conn.Close()
+
+	reply, err := redis.Values(conn.Do("CLUSTER", "SLOTS"))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return parseClusterSlots(reply, addr)
+}
+
+// nodeFor returns the address of the node serving the key (empty if unknown)
+func (c *redisCluster) nodeFor(key string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.owners[keySlot(key)]
+}
+
+// redirect returns the address to retry the command at if the error is a cluster redirection
+// (MOVED or ASK); in case of MOVED, the slot owner is updated
+func (c *redisCluster) redirect(err error) (addr string, ask bool, ok bool) {
+	var redisErr redis.Error
+
+	if !errors.As(err, &redisErr) {
+		return "", false, false
+	}
+
+	// Format: "MOVED <slot> <host>:<port>" or "ASK <slot> <host>:<port>"
+	parts := strings.Fields(string(redisErr))
+
+	if len(parts) != 3 || (parts[0] != "MOVED" && parts[0] != "ASK") {
+		return "", false, false
+	}
+
+	slot, convErr := strconv.Atoi(parts[1])
+
+	if convErr != nil || slot < 0 || slot >= redisClusterSlots {
+		return "", false, false
+	}
+
+	if parts[0] == "ASK" {
+		return parts[2], true, true
+	}
+
+	c.mu.Lock()
+	c.owners[slot] = parts[2]
+	c.mu.Unlock()
+
+	return parts[2], false, true
+}
+
+// parseClusterSlots parses CLUSTER SLOTS reply: [[start, end, [host, port, ...], replicas...], ...].
+// An empty host means the host of the node the reply was received from.
+func parseClusterSlots(reply []interface{}, addr string) ([]string, error) {
+	owners := make([]string, redisClusterSlots)
+	defaultHost, _, _ := net.SplitHostPort(addr)
+
+	for _, item := range reply {
+		entry, err := redis.Values(item, nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(entry) < 3 {
+			return nil, errors.New("Malformed CLUSTER SLOTS reply")
+		}
+
+		start, err := redis.Int(entry[0], nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		end, err := redis.Int(entry[1], nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if start < 0 || end >= redisClusterSlots || start > end {
+			return nil, fmt.Errorf("Invalid slots range in CLUSTER SLOTS reply: %d-%d", start, end)
+		}
+
+		master, err := redis.Values(entry[2], nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(master) < 2 {
+			return nil, errors.New("Malformed CLUSTER SLOTS reply")
+		}
+
+		host, err := redis.String(master[0], nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		port, err := redis.Int(master[1], nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if host == "" {
+			host = defaultHost
+		}
+
+		node := net.JoinHostPort(host, strconv.Itoa(port))
+
+		for slot := start; slot <= end; slot++ {
+			owners[slot] = node
+		}
+	}
+
+	return owners, nil
+}
+
+// keySlot returns the hash slot of the key (taking hash tags into account)
+func keySlot(key string) int {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			key = key[start+1 : start+1+end]
+		}
+	}
+
+	return int(crc16(key)) % redisClusterSlots
+}
+
+// crc16 implements CRC16-CCITT (XModem) used by Redis Cluster to calculate hash slots
+func crc16(s string) uint16 {
+	var crc uint16
+
+	for i := 0; i < len(s); i++ {
+		crc ^= uint16(s[i]) << 8
+
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+
+	return crc
+}
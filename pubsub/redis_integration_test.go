@@ -0,0 +1,228 @@
+package pubsub
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// These tests run against a local redis-server (6.2+) and are skipped if it's not available
+
+func TestRedisIntegrationACL(t *testing.T) {
+	port := runRedisServer(t, false,
+		"--user", "default", "off",
+		"--user", "anycable", "on", ">secret", "~*", "&*", "+@all",
+	)
+
+	config := NewRedisConfig()
+	config.URL = fmt.Sprintf("redis://localhost:%d", port)
+	config.Username = "anycable"
+	config.Password = "secret"
+
+	testRedisBroadcast(t, &config)
+
+	t.Run("With invalid credentials", func(t *testing.T) {
+		config.Password = "wrong"
+
+		publisher := NewRedisPublisher(&config)
+		require.NoError(t, publisher.Start())
+		defer publisher.Shutdown() // nolint:errcheck
+
+		assert.Error(t, publisher.Publish([]byte(`{"stream":"chat_1","data":"hi"}`)))
+	})
+}
+
+func TestRedisIntegrationTLS(t *testing.T) {
+	certs := generateTestCertificates(t)
+
+	port := runRedisServer(t, true,
+		"--tls-cert-file", certs.serverCert,
+		"--tls-key-file", certs.serverKey,
+		"--tls-ca-cert-file", certs.ca,
+		"--tls-auth-clients", "yes",
+	)
+
+	config := NewRedisConfig()
+	config.URL = fmt.Sprintf("rediss://localhost:%d", port)
+	config.TLSVerify = true
+	config.TLSCA = certs.ca
+	config.TLSCert = certs.clientCert
+	config.TLSKey = certs.clientKey
+
+	testRedisBroadcast(t, &config)
+
+	t.Run("Without client certificate", func(t *testing.T) {
+		config := NewRedisConfig()
+		config.URL = fmt.Sprintf("rediss://localhost:%d", port)
+		config.TLSVerify = true
+		config.TLSCA = certs.ca
+
+		publisher := NewRedisPublisher(&config)
+		require.NoError(t, publisher.Start())
+		defer publisher.Shutdown() // nolint:errcheck
+
+		assert.Error(t, publisher.Publish([]byte(`{"stream":"chat_1","data":"hi"}`)))
+	})
+}
+
+func TestRedisIntegrationCluster(t *testing.T) {
+	dir := t.TempDir()
+
+	port := runRedisServer(t, false,
+		"--cluster-enabled", "yes",
+		"--cluster-config-file", dir+"/nodes.conf",
+	)
+
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+
+	c, err := redis.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer c.Close()
+
+	if info, err := redis.Values(c.Do("COMMAND", "INFO", "SPUBLISH")); err != nil || len(info) == 0 || info[0] == nil {
+		t.Skip("Sharded pub/sub is not supported by redis-server")
+	}
+
+	// Single node cluster serving all the slots
+	slots := make([]interface{}, redisClusterSlots)
+
+	for i := range slots {
+		slots[i] = i
+	}
+
+	_, err = c.Do("CLUSTER", append([]interface{}{"ADDSLOTS"}, slots...)...)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		info, err := redis.String(c.Do("CLUSTER", "INFO"))
+		return err == nil && strings.Contains(info, "cluster_state:ok")
+	}, 5*time.Second, 50*time.Millisecond)
+
+	config := NewRedisConfig()
+	config.ClusterNodes = addr
+	config.Sharded = true
+
+	handler := &testHandler{messages: make(chan string, 10)}
+	subscriber := NewRedisSubscriber(handler, &config)
+
+	require.NoError(t, subscriber.Start(make(chan error, 1)))
+	defer subscriber.Shutdown() // nolint:errcheck
+
+	require.Eventually(t, subscriber.Ready, 5*time.Second, 10*time.Millisecond)
+
+	subscriber.SubscribeStream("chat_1")
+
+	publisher := NewRedisPublisher(&config)
+	require.NoError(t, publisher.Start())
+	defer publisher.Shutdown() // nolint:errcheck
+
+	msg := `{"stream":"chat_1","data":"hi"}`
+
+	// Shard connection is established asynchronously
+	require.Eventually(t, func() bool {
+		require.NoError(t, publisher.Publish([]byte(msg)))
+
+		select {
+		case received := <-handler.messages:
+			return received == msg
+		case <-time.After(100 * time.Millisecond):
+			return false
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, addr, publisher.cluster.nodeFor(streamChannel(config.Channel, "chat_1")))
+}
+
+func testRedisBroadcast(t *testing.T, config *RedisConfig) {
+	handler := &testHandler{messages: make(chan string, 10)}
+	subscriber := NewRedisSubscriber(handler, config)
+
+	require.NoError(t, subscriber.Start(make(chan error, 1)))
+	defer subscriber.Shutdown() // nolint:errcheck
+
+	require.Eventually(t, subscriber.Ready, 5*time.Second, 10*time.Millisecond)
+
+	publisher := NewRedisPublisher(config)
+	require.NoError(t, publisher.Start())
+	defer publisher.Shutdown() // nolint:errcheck
+
+	msg := `{"stream":"chat_1","data":"hi"}`
+
+	require.NoError(t, publisher.Publish([]byte(msg)))
+	assert.Equal(t, msg, handler.Receive(t))
+}
+
+// runRedisServer starts redis-server with the provided configuration directives and returns its port
+// (TLS-only if tls is true)
+func runRedisServer(t *testing.T, tls bool, args ...string) int {
+	bin, err := exec.LookPath("redis-server")
+
+	if err != nil {
+		t.Skip("redis-server is not available")
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	serverArgs := []string{"--bind", "127.0.0.1", "--save", "", "--appendonly", "no", "--dir", t.TempDir()}
+
+	if tls {
+		serverArgs = append(serverArgs, "--port", "0", "--tls-port", strconv.Itoa(port))
+	} else {
+		serverArgs = append(serverArgs, "--port", strconv.Itoa(port))
+	}
+
+	var output bytes.Buffer
+
+	cmd := exec.Command(bin, append(serverArgs, args...)...) // #nosec
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	require.NoError(t, cmd.Start())
+
+	exited := make(chan struct{})
+
+	go func() {
+		cmd.Wait() // nolint:errcheck
+		close(exited)
+	}()
+
+	t.Cleanup(func() {
+		cmd.Process.Kill() // nolint:errcheck
+		<-exited
+	})
+
+	deadline := time.Now().Add(5 * time.Second)
+
+	for time.Now().Before(deadline) {
+		select {
+		case <-exited:
+			// E.g., redis-server is built without TLS support
+			t.Skipf("redis-server failed to start: %s", output.String())
+		default:
+		}
+
+		if conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port)); err == nil {
+			conn.Close()
+			return port
+		}
+
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	t.Fatal("redis-server hasn't started in time")
+
+	return 0
+}
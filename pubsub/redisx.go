@@ -0,0 +1,496 @@
+package pubsub
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/FZambia/sentinel"
+	"github.com/anycable/anycable-go/metrics"
+	"github.com/apex/log"
+	"github.com/gomodule/redigo/redis"
+)
+
+const (
+	defaultRedisStreamMaxLen = 10000
+
+	// Stream entry field containing the broadcast payload
+	redisxPayloadField = "payload"
+	// The max number of entries to read at once
+	redisxReadCount = 100
+	// How long to block waiting for new entries
+	redisxBlockTimeout = 5 * time.Second
+
+	metricsRedisXLag       = "redisx_lag_ms"
+	metricsRedisXConnected = "redisx_connected"
+)
+
+// RedisXSubscriber reads broadcasts from a Redis Stream and keeps track of the last read entry,
+// so no messages are lost during reconnects (unless they have been trimmed)
+type RedisXSubscriber struct {
+	node   Handler
+	config *RedisConfig
+	dial   func() (redis.Conn, error)
+
+	// ID of the last read entry
+	lastID           string
+	reconnectAttempt int
+
+	conn       redis.Conn
+	mu         sync.Mutex
+	shutdownCh chan struct{}
+
+	connectionState
+
+	metrics metrics.Instrumenter
+	log     *log.Entry
+}
+
+var _ Subscriber = (*RedisXSubscriber)(nil)
+var _ StatefulSubscriber = (*RedisXSubscriber)(nil)
+
+// NewRedisXSubscriber returns new RedisXSubscriber struct
+func NewRedisXSubscriber(node Handler, config *RedisConfig) *RedisXSubscriber {
+	return &RedisXSubscriber{
+		node:            node,
+		config:          config,
+		shutdownCh:      make(chan struct{}),
+		connectionState: newConnectionState(metricsRedisXConnected),
+		metrics:         metrics.NoopMetrics{},
+		log:             log.WithFields(log.Fields{"context": "pubsub", "provider": "redisx"}),
+	}
+}
+
+// SetMetrics sets the instrumenter to report the subscriber lag and connection state to
+func (s *RedisXSubscriber) SetMetrics(m metrics.Instrumenter) {
+	s.metrics = m
+	s.metrics.RegisterGauge(metricsRedisXLag, "The time between adding the last read broadcast to the Redis stream and reading it (in milliseconds)")
+	s.registerMetrics(m, "Whether the Redis Streams subscriber is connected (1) or not (0)")
+}
+
+// Start connects to Redis and starts reading the stream
+func (s *RedisXSubscriber) Start(done chan (error)) error {
+	if s.dial == nil {
+		dial, err := newRedisDialer(s.config, s.log)
+
+		if err != nil {
+			return err
+		}
+
+		s.dial = dial
+	}
+
+	go s.keepalive(done)
+
+	return nil
+}
+
+// Shutdown stops reading the stream
+func (s *RedisXSubscriber) Shutdown() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	select {
+	case <-s.shutdownCh:
+		return nil
+	default:
+	}
+
+	close(s.shutdownCh)
+
+	// Interrupt blocking read
+	if s.conn != nil {
+		s.conn.Close()
+	}
+
+	return nil
+}
+
+func (s *RedisXSubscriber) keepalive(done chan (error)) {
+	for {
+		err := s.listen()
+
+		s.setConnected(false)
+
+		if s.stopped() {
+			return
+		}
+
+		if err != nil {
+			s.log.Warnf("Redis connection failed: %v", err)
+		}
+
+		s.reconnectAttempt++
+
+		delay, ok := reconnectDelay(s.reconnectAttempt, s.config.MaxReconnectAttempts, time.Duration(s.config.MaxReconnectDelay)*time.Second)
+
+		if !ok {
+			done <- errors.New("Redis reconnect attempts exceeded") //nolint:stylecheck
+			return
+		}
+
+		s.log.Infof("Next Redis reconnect attempt in %s", delay)
+
+		select {
+		case <-s.shutdownCh:
+			return
+		case <-time.After(delay):
+		}
+
+		s.log.Infof("Reconnecting to Redis...")
+	}
+}
+
+func (s *RedisXSubscriber) listen() error {
+	c, err := s.dial()
+
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	if s.stopped() {
+		s.mu.Unlock()
+		c.Close()
+		return nil
+	}
+	s.conn = c
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		s.conn = nil
+		s.mu.Unlock()
+		c.Close()
+	}()
+
+	if s.lastID == "" {
+		if s.lastID, err = s.tailID(c); err != nil {
+			return err
+		}
+
+		s.log.Infof("Reading broadcasts from Redis stream: %s", s.config.Channel)
+	} else {
+		s.log.Infof("Resuming reading broadcasts from Redis stream %s after %s", s.config.Channel, s.lastID)
+
+		if err = s.checkTrimmed(c); err != nil {
+			return err
+		}
+	}
+
+	s.reconnectAttempt = 0
+	s.setConnected(true)
+
+	for {
+		if s.stopped() {
+			return nil
+		}
+
+		reply, err := redis.Values(
+			c.Do(
+				"XREAD",
+				"COUNT", redisxReadCount,
+				"BLOCK", redisxBlockTimeout.Milliseconds(),
+				"STREAMS", s.config.Channel, s.lastID,
+			),
+		)
+
+		if err == redis.ErrNil {
+			continue
+		}
+
+		if err != nil {
+			// Connection has been closed on shutdown
+			if s.stopped() {
+				return nil
+			}
+
+			return err
+		}
+
+		entries, err := readStreamEntries(reply)
+
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			s.lastID = entry.id
+
+			if entry.payload == nil {
+				s.log.Warnf("Stream entry %s has no %s field", entry.id, redisxPayloadField)
+				continue
+			}
+
+			s.log.Debugf("Incoming pubsub message from Redis stream: %s", entry.payload)
+			s.node.HandlePubSub(entry.payload)
+		}
+
+		s.metrics.GaugeSet(metricsRedisXLag, streamEntryLag(s.lastID, time.Now()))
+	}
+}
+
+// tailID returns the ID of the last stream entry (or the minimal ID if the stream is empty),
+// so we start reading new messages only
+func (s *RedisXSubscriber) tailID(c redis.Conn) (string, error) {
+	reply, err := redis.Values(c.Do("XREVRANGE", s.config.Channel, "+", "-", "COUNT", 1))
+
+	if err != nil {
+		return "", err
+	}
+
+	if len(reply) == 0 {
+		return "0-0", nil
+	}
+
+	entries, err := parseStreamEntries(reply)
+
+	if err != nil {
+		return "", err
+	}
+
+	return entries[0].id, nil
+}
+
+// checkTrimmed warns if the stream has been trimmed past the last read entry
+// (so some broadcasts could be lost while reconnecting)
+func (s *RedisXSubscriber) checkTrimmed(c redis.Conn) error {
+	reply, err := redis.Values(c.Do("XRANGE", s.config.Channel, "-", "+", "COUNT", 1))
+
+	if err != nil {
+		return err
+	}
+
+	if len(reply) == 0 {
+		return nil
+	}
+
+	entries, err := parseStreamEntries(reply)
+
+	if err != nil {
+		return err
+	}
+
+	if headID := entries[0].id; compareStreamIDs(s.lastID, headID) < 0 {
+		s.log.Warnf("Redis stream %s has been trimmed past the last read entry %s (the oldest entry is %s), some broadcasts could have been lost; consider increasing redis_stream_max_len", s.config.Channel, s.lastID, headID)
+	}
+
+	return nil
+}
+
+func (s *RedisXSubscriber) stopped() bool {
+	select {
+	case <-s.shutdownCh:
+		return true
+	default:
+		return false
+	}
+}
+
+// RedisXPublisher adds messages to the Redis Stream (trimming it to the configured length)
+type RedisXPublisher struct {
+	*RedisPublisher
+
+	maxLen int
+}
+
+var _ Publisher = (*RedisXPublisher)(nil)
+
+// NewRedisXPublisher returns new RedisXPublisher struct
+func NewRedisXPublisher(config *RedisConfig) *RedisXPublisher {
+	return &RedisXPublisher{
+		RedisPublisher: NewRedisPublisher(config),
+		maxLen:         config.StreamMaxLen,
+	}
+}
+
+// Publish adds the message to the Redis Stream (reconnecting if necessary)
+func (p *RedisXPublisher) Publish(msg []byte) error {
+	_, err := p.do(p.channel, "XADD", p.channel, "MAXLEN", "~", p.maxLen, "*", redisxPayloadField, msg)
+
+	return err
+}
+
+type streamEntry struct {
+	id      string
+	payload []byte
+}
+
+// readStreamEntries parses XREAD reply for a single stream: [[key, [[id, [field, value, ...]], ...]]]
+func readStreamEntries(reply []interface{}) ([]*streamEntry, error) {
+	if len(reply) == 0 {
+		return nil, nil
+	}
+
+	stream, err := redis.Values(reply[0], nil)
+
+	if err != nil || len(stream) != 2 {
+		return nil, fmt.Errorf("Unexpected XREAD reply: %v", reply)
+	}
+
+	entries, err := redis.Values(stream[1], nil)
+
+	if err != nil {
+		return nil, fmt.Errorf("Unexpected XREAD reply: %v", reply)
+	}
+
+	return parseStreamEntries(entries)
+}
+
+// parseStreamEntries parses the list of entries: [[id, [field, value, ...]], ...]
+func parseStreamEntries(reply []interface{}) ([]*streamEntry, error) {
+	entries := make([]*streamEntry, 0, len(reply))
+
+	for _, raw := range reply {
+		entry, err := redis.Values(raw, nil)
+
+		if err != nil || len(entry) != 2 {
+			return nil, fmt.Errorf("Unexpected stream entry: %v", raw)
+		}
+
+		id, err := redis.String(entry[0], nil)
+
+		if err != nil {
+			return nil, fmt.Errorf("Unexpected stream entry ID: %v", entry[0])
+		}
+
+		fields, err := redis.ByteSlices(entry[1], nil)
+
+		if err != nil {
+			return nil, fmt.Errorf("Unexpected stream entry fields: %v", entry[1])
+		}
+
+		parsed := &streamEntry{id: id}
+
+		for i := 0; i+1 < len(fields); i += 2 {
+			if string(fields[i]) == redisxPayloadField {
+				parsed.payload = fields[i+1]
+			}
+		}
+
+		entries = append(entries, parsed)
+	}
+
+	return entries, nil
+}
+
+// streamEntryLag returns the number of milliseconds passed since the entry was added
+// (stream entry IDs start with the Unix time in milliseconds)
+func streamEntryLag(id string, now time.Time) uint64 {
+	ms, err := strconv.ParseInt(strings.SplitN(id, "-", 2)[0], 10, 64)
+
+	if err != nil {
+		return 0
+	}
+
+	lag := now.UnixMilli() - ms
+
+	if lag < 0 {
+		return 0
+	}
+
+	return uint64(lag)
+}
+
+// compareStreamIDs compares stream entries IDs ("<ms>-<seq>"); returns -1, 0 or 1
+func compareStreamIDs(a string, b string) int {
+	aMs, aSeq := parseStreamID(a)
+	bMs, bSeq := parseStreamID(b)
+
+	switch {
+	case aMs < bMs || (aMs == bMs && aSeq < bSeq):
+		return -1
+	case aMs == bMs && aSeq == bSeq:
+		return 0
+	default:
+		return 1
+	}
+}
+
+func parseStreamID(id string) (uint64, uint64) {
+	parts := strings.SplitN(id, "-", 2)
+
+	ms, _ := strconv.ParseUint(parts[0], 10, 64)
+
+	var seq uint64
+
+	if len(parts) == 2 {
+		seq, _ = strconv.ParseUint(parts[1], 10, 64)
+	}
+
+	return ms, seq
+}
+
+// newRedisDialer returns a function to connect to Redis (resolving the master via sentinels
+// or the node serving the stream via cluster nodes if configured)
+func newRedisDialer(config *RedisConfig, l *log.Entry) (func() (redis.Conn, error), error) {
+	uri, err := redisURL(config)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig, err := redisTLSConfig(config)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var sentinelClient *sentinel.Sentinel
+
+	if config.Sentinels != "" {
+		sentinelClient = newSentinelClient(config.Sentinels, uri.Hostname(), tlsConfig, l)
+	}
+
+	var cluster *redisCluster
+
+	if config.ClusterNodes != "" {
+		cluster = newRedisCluster(config.ClusterNodes, uri, redis.DialTLSConfig(tlsConfig))
+	}
+
+	return func() (redis.Conn, error) {
+		if cluster != nil {
+			if err := cluster.refresh(); err != nil {
+				return nil, err
+			}
+
+			addr := cluster.nodeFor(config.Channel)
+
+			if addr == "" {
+				return nil, fmt.Errorf("No Redis cluster node serves the stream: %s", config.Channel)
+			}
+
+			return cluster.dial(addr)
+		}
+
+		addr := uri.String()
+
+		if sentinelClient != nil {
+			masterAddress, err := sentinelClient.MasterAddr()
+
+			if err != nil {
+				return nil, err
+			}
+
+			masterURI := *uri
+			masterURI.Host = masterAddress
+			addr = masterURI.String()
+		}
+
+		c, err := redis.DialURL(addr, redis.DialTLSConfig(tlsConfig))
+
+		if err != nil {
+			return nil, err
+		}
+
+		if sentinelClient != nil && !sentinel.TestRole(c, "master") {
+			c.Close()
+			return nil, errors.New("Failed master role check")
+		}
+
+		return c, nil
+	}, nil
+}
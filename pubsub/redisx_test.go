@@ -0,0 +1,232 @@
+package pubsub
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRedisStream is an in-memory Redis stream supporting XRANGE, XREVRANGE and XREAD commands
+type fakeRedisStream struct {
+	mu      sync.Mutex
+	entries [][]interface{}
+	conns   []*fakeRedisConn
+	added   chan struct{}
+	// Notified on every XREAD call
+	reads chan struct{}
+}
+
+func newFakeRedisStream() *fakeRedisStream {
+	return &fakeRedisStream{added: make(chan struct{}, 100), reads: make(chan struct{}, 1)}
+}
+
+func (f *fakeRedisStream) Add(payload string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	id := fmt.Sprintf("%d-%d", time.Now().UnixMilli(), len(f.entries)+1)
+	f.entries = append(f.entries, []interface{}{[]byte(id), []interface{}{[]byte("payload"), []byte(payload)}})
+	f.added <- struct{}{}
+}
+
+// DropConnections makes all the active connections fail
+func (f *fakeRedisStream) DropConnections() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, c := range f.conns {
+		c.Close()
+	}
+
+	f.conns = nil
+}
+
+func (f *fakeRedisStream) Dial() (redis.Conn, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	c := &fakeRedisConn{stream: f, closed: make(chan struct{})}
+	f.conns = append(f.conns, c)
+
+	return c, nil
+}
+
+// after returns entries added after the specified ID (IDs sequence numbers are entries positions)
+func (f *fakeRedisStream) after(id string) []interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	seq, _ := strconv.Atoi(strings.SplitN(id, "-", 2)[1])
+
+	res := []interface{}{}
+
+	for _, entry := range f.entries[seq:] {
+		res = append(res, entry)
+	}
+
+	return res
+}
+
+type fakeRedisConn struct {
+	stream *fakeRedisStream
+	closed chan struct{}
+	once   sync.Once
+}
+
+func (c *fakeRedisConn) Close() error {
+	c.once.Do(func() { close(c.closed) })
+	return nil
+}
+
+func (c *fakeRedisConn) Err() error {
+	return nil
+}
+
+func (c *fakeRedisConn) Do(cmd string, args ...interface{}) (interface{}, error) {
+	select {
+	case <-c.closed:
+		return nil, errors.New("connection closed")
+	default:
+	}
+
+	switch cmd {
+	case "XREVRANGE":
+		entries := c.stream.after("0-0")
+
+		if len(entries) == 0 {
+			return []interface{}{}, nil
+		}
+
+		return []interface{}{entries[len(entries)-1]}, nil
+	case "XRANGE":
+		entries := c.stream.after("0-0")
+
+		if len(entries) == 0 {
+			return []interface{}{}, nil
+		}
+
+		return []interface{}{entries[0]}, nil
+	case "XREAD":
+		lastID := args[len(args)-1].(string)
+
+		select {
+		case c.stream.reads <- struct{}{}:
+		default:
+		}
+
+		for {
+			if entries := c.stream.after(lastID); len(entries) > 0 {
+				return []interface{}{[]interface{}{[]byte("__anycable__"), entries}}, nil
+			}
+
+			select {
+			case <-c.closed:
+				return nil, errors.New("connection closed")
+			case <-c.stream.added:
+			case <-time.After(50 * time.Millisecond):
+				return nil, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("Unknown command: %s", cmd)
+}
+
+func (c *fakeRedisConn) Send(cmd string, args ...interface{}) error {
+	return nil
+}
+
+func (c *fakeRedisConn) Flush() error {
+	return nil
+}
+
+func (c *fakeRedisConn) Receive() (interface{}, error) {
+	return nil, nil
+}
+
+type testHandler struct {
+	messages chan string
+}
+
+func (h *testHandler) HandlePubSub(msg []byte) {
+	h.messages <- string(msg)
+}
+
+func (h *testHandler) Receive(t *testing.T) string {
+	select {
+	case msg := <-h.messages:
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("Timed out to receive message")
+	}
+
+	return ""
+}
+
+func TestRedisXSubscriber(t *testing.T) {
+	stream := newFakeRedisStream()
+	handler := &testHandler{messages: make(chan string, 10)}
+
+	config := NewRedisConfig()
+	subscriber := NewRedisXSubscriber(handler, &config)
+	subscriber.dial = stream.Dial
+
+	// Messages published before the subscriber started must be skipped
+	stream.Add("old")
+
+	listen := func() chan error {
+		errCh := make(chan error, 1)
+
+		go func() {
+			errCh <- subscriber.listen()
+		}()
+
+		return errCh
+	}
+
+	errCh := listen()
+
+	// Wait for the subscriber to start reading
+	<-stream.reads
+
+	stream.Add("first")
+	assert.Equal(t, "first", handler.Receive(t))
+
+	stream.DropConnections()
+	require.Error(t, <-errCh)
+
+	stream.Add("second")
+	stream.Add("third")
+
+	errCh = listen()
+
+	assert.Equal(t, "second", handler.Receive(t))
+	assert.Equal(t, "third", handler.Receive(t))
+
+	require.NoError(t, subscriber.Shutdown())
+	require.NoError(t, <-errCh)
+}
+
+func TestStreamEntryLag(t *testing.T) {
+	now := time.UnixMilli(1700000001500)
+
+	assert.Equal(t, uint64(500), streamEntryLag("1700000001000-0", now))
+	assert.Equal(t, uint64(0), streamEntryLag("1700000002000-0", now))
+	assert.Equal(t, uint64(0), streamEntryLag("invalid", now))
+}
+
+func TestCompareStreamIDs(t *testing.T) {
+	assert.Equal(t, -1, compareStreamIDs("1700000001000-0", "1700000001000-1"))
+	assert.Equal(t, -1, compareStreamIDs("1700000001000-5", "1700000002000-0"))
+	assert.Equal(t, -1, compareStreamIDs("999-0", "1000-0"))
+	assert.Equal(t, 0, compareStreamIDs("1700000001000-1", "1700000001000-1"))
+	assert.Equal(t, 1, compareStreamIDs("1700000002000-0", "1700000001000-9"))
+}
@@ -2,6 +2,9 @@ package pubsub
 
 import (
 	"fmt"
+	"sync/atomic"
+
+	"github.com/anycable/anycable-go/metrics"
 )
 
 // Subscriber is responsible for receiving broadcast messages
@@ -11,20 +14,146 @@ type Subscriber interface {
 	Shutdown() error
 }
 
+// Instrumentable is implemented by subscribers reporting their own metrics
+type Instrumentable interface {
+	SetMetrics(m metrics.Instrumenter)
+}
+
+// StreamsSubscriber is implemented by subscribers using stream-specific channels,
+// so they must be notified about the streams with local subscribers
+type StreamsSubscriber interface {
+	SubscribeStream(stream string)
+	UnsubscribeStream(stream string)
+}
+
+// StatefulSubscriber is implemented by subscribers tracking their connection state
+type StatefulSubscriber interface {
+	// Ready returns true if the subscriber is connected and receives broadcasts
+	Ready() bool
+	// OnStateChange registers a callback to be called when the subscriber gets connected or disconnected
+	OnStateChange(fn func(ready bool))
+}
+
 type Handler interface {
 	HandlePubSub(json []byte)
 }
 
 // NewSubscriber creates an instance of the provided adapter
+// (or a MultiSubscriber if a comma-separated list of adapters is provided)
 func NewSubscriber(node Handler, adapter string, redis *RedisConfig, http *HTTPConfig, nats *NATSConfig) (Subscriber, error) {
+	if adapters := splitAdapters(adapter); len(adapters) > 1 {
+		return newMultiSubscriber(node, adapters, redis, http, nats)
+	}
+
 	switch adapter {
 	case "redis":
 		return NewRedisSubscriber(node, redis), nil
+	case "redisx":
+		return NewRedisXSubscriber(node, redis), nil
 	case "http":
-		return NewHTTPSubscriber(node, http), nil
+		if http.Bus == "" {
+			return NewHTTPSubscriber(node, http), nil
+		}
+
+		if !IsBusAdapter(http.Bus) {
+			return nil, fmt.Errorf("Unsupported HTTP broadcast bus: %s", http.Bus)
+		}
+
+		bus, err := NewSubscriber(node, http.Bus, redis, http, nats)
+
+		if err != nil {
+			return nil, err
+		}
+
+		publisher, err := NewPublisher(http.Bus, redis, http, nats)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return NewHTTPBusSubscriber(node, http, publisher, bus), nil
 	case "nats":
 		return NewNATSSubscriber(node, nats), nil
+	case "jetstream":
+		return NewJetStreamSubscriber(node, nats), nil
 	}
 
 	return nil, fmt.Errorf("Unknown adapter type: %s", adapter)
 }
+
+func newMultiSubscriber(node Handler, adapters []string, redis *RedisConfig, http *HTTPConfig, nats *NATSConfig) (Subscriber, error) {
+	subscribers := make([]Subscriber, 0, len(adapters))
+	seen := make(map[string]bool, len(adapters))
+
+	for _, adapter := range adapters {
+		if seen[adapter] {
+			return nil, fmt.Errorf("Duplicate adapter: %s", adapter)
+		}
+
+		seen[adapter] = true
+	}
+
+	// HTTP subscriber starts the bus subscriber itself
+	if seen["http"] && seen[http.Bus] {
+		return nil, fmt.Errorf("HTTP broadcast bus is already used as an adapter: %s", http.Bus)
+	}
+
+	for _, adapter := range adapters {
+		subscriber, err := NewSubscriber(node, adapter, redis, http, nats)
+
+		if err != nil {
+			return nil, err
+		}
+
+		subscribers = append(subscribers, subscriber)
+	}
+
+	return NewMultiSubscriber(subscribers...), nil
+}
+
+// connectionState tracks the subscriber connection state (reporting it via metrics)
+// and notifies about state changes
+type connectionState struct {
+	connected int32
+	gauge     string
+	metrics   metrics.Instrumenter
+	onChange  func(ready bool)
+}
+
+func newConnectionState(gauge string) connectionState {
+	return connectionState{gauge: gauge, metrics: metrics.NoopMetrics{}}
+}
+
+// Ready returns true if the subscriber is connected
+func (c *connectionState) Ready() bool {
+	return atomic.LoadInt32(&c.connected) == 1
+}
+
+// OnStateChange registers a callback to be called when the subscriber gets connected or disconnected
+// (must be called before the subscriber is started)
+func (c *connectionState) OnStateChange(fn func(ready bool)) {
+	c.onChange = fn
+}
+
+func (c *connectionState) registerMetrics(m metrics.Instrumenter, desc string) {
+	c.metrics = m
+	c.metrics.RegisterGauge(c.gauge, desc)
+}
+
+func (c *connectionState) setConnected(connected bool) {
+	var val int32
+
+	if connected {
+		val = 1
+	}
+
+	if atomic.SwapInt32(&c.connected, val) == val {
+		return
+	}
+
+	c.metrics.GaugeSet(c.gauge, uint64(val))
+
+	if c.onChange != nil {
+		c.onChange(connected)
+	}
+}
@@ -10,3 +10,16 @@ func HealthHandler(w http.ResponseWriter, _ *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	w.Write(healthMsg) //nolint:errcheck
 }
+
+// UnavailableWhen responds with 503 status while the provided predicate is true
+// (e.g., when the node is draining connections) and delegates to the handler otherwise
+func UnavailableWhen(unavailable func() bool, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if unavailable() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"sync"
+)
+
+// KeyedScheduler executes tasks with the same key one by one in the order they were scheduled.
+// Tasks with different keys are executed concurrently using the underlying pool.
+type KeyedScheduler struct {
+	pool *GoPool
+
+	// key -> pending tasks (the key is present while its tasks are being executed)
+	queues map[string][]func()
+	mu     sync.Mutex
+}
+
+// NewKeyedScheduler creates a new KeyedScheduler on top of the pool
+func NewKeyedScheduler(pool *GoPool) *KeyedScheduler {
+	return &KeyedScheduler{pool: pool, queues: make(map[string][]func())}
+}
+
+// Schedule enqueues the task for the key. The task is executed after all the previously
+// scheduled tasks with the same key have been completed.
+func (s *KeyedScheduler) Schedule(key string, task func()) {
+	s.mu.Lock()
+	queue, active := s.queues[key]
+	s.queues[key] = append(queue, task)
+	s.mu.Unlock()
+
+	if !active {
+		s.pool.Schedule(func() { s.drain(key) })
+	}
+}
+
+// Size returns the number of keys with pending or running tasks
+func (s *KeyedScheduler) Size() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.queues)
+}
+
+func (s *KeyedScheduler) drain(key string) {
+	for {
+		s.mu.Lock()
+		queue := s.queues[key]
+
+		if len(queue) == 0 {
+			delete(s.queues, key)
+			s.mu.Unlock()
+			return
+		}
+
+		task := queue[0]
+		queue[0] = nil
+		s.queues[key] = queue[1:]
+		s.mu.Unlock()
+
+		task()
+	}
+}
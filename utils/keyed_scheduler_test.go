@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyedSchedulerOrder(t *testing.T) {
+	scheduler := NewKeyedScheduler(NewGoPool("keyed", 8))
+
+	keysNum := 10
+	tasksNum := 1000
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+
+	results := make(map[string][]int)
+
+	wg.Add(keysNum * tasksNum)
+
+	for i := 0; i < tasksNum; i++ {
+		for k := 0; k < keysNum; k++ {
+			key := fmt.Sprintf("key_%d", k)
+			seq := i
+
+			scheduler.Schedule(key, func() {
+				mu.Lock()
+				results[key] = append(results[key], seq)
+				mu.Unlock()
+				wg.Done()
+			})
+		}
+	}
+
+	wg.Wait()
+
+	for k := 0; k < keysNum; k++ {
+		seqs := results[fmt.Sprintf("key_%d", k)]
+
+		assert.Len(t, seqs, tasksNum)
+
+		for i, seq := range seqs {
+			if !assert.Equal(t, i, seq) {
+				break
+			}
+		}
+	}
+
+	assert.Eventually(t, func() bool { return scheduler.Size() == 0 }, time.Second, 10*time.Millisecond)
+}